@@ -0,0 +1,710 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"distributed_ledger_go/pkg/audit"
+	"distributed_ledger_go/pkg/client"
+)
+
+const usage = `ledgerctl - 分布式账本命令行钱包与运维工具
+
+用法:
+  ledgerctl [全局参数] <子命令> [参数]
+
+全局参数:
+  -node      节点地址，多个以逗号分隔（默认 127.0.0.1:8101）
+  -keystore  本地密钥库目录（默认 ./keystore）
+  -output    输出格式 table|json（默认 table）
+  -chain     签名使用的 chain id（默认从节点读取）
+  -consistency
+             账户查询的读一致性 linearizable|leader|stale（默认 stale）
+  -trusted   信任的节点身份地址，多个以逗号分隔；prove、consistency、checkpoints 拒绝其它签名者
+
+子命令:
+  keygen                              生成密钥对并保存到密钥库
+  keys                                列出密钥库中的地址
+  import <private_key>                导入 hex 私钥
+  register                            注册账户并保存返回的私钥
+  account <address>                   查询账户
+  mint <sender> <receiver> <amount>   铸币
+  transfer <sender> <receiver> <amount>
+                                      转账
+  burn <sender> <amount>              销毁发送方自己的余额
+  freeze <sender> <target>            冻结账户
+  unfreeze <sender> <target>          解冻账户
+  promote <creator> <target>          提升为管理员
+  demote <creator> <target>           降级为普通用户
+  query <requester>                   查询可见流水
+  history <requester> <address> [-type N] [-counterparty ADDR] [-from N] [-to N] [-since T] [-until T] [-cursor N] [-limit N]
+                                      分页查询账户流水
+  receipt <tx_hash>                   按交易哈希查询回执
+  audit <index>                       查看审计条目
+  audit-range <from_time> [to_time]   列出时间范围（Unix 秒）内的审计条目
+  prove <index> [tree_size]           获取并在本地校验审计条目的包含性证明
+  consistency <old_size> <old_root> [new_size]
+                                      校验新树是此前保存的旧树的追加扩展
+  audit-head                          查看审计链头部
+  checkpoints [from]                  校验 leader 签名的检查点并与该节点审计链比对
+  audit-export [from]                 以 NDJSON 导出审计链到标准输出
+  verify                              从节点拉取审计链并在本地校验
+  audit-verify <requester> [start]    以管理员身份查看（或重新触发）节点后台全量审计校验进度
+  audit-clear-divergence <requester>  以管理员身份清除 -node 指定节点的审计链分叉记录，恢复其写入
+  supply [verify]                     查看全网发行量（verify 时校验账户余额之和）
+  policy [address]                    查看额度策略（指定地址时附带其生效限制与使用量）
+  set-policy <creator> <file|->       从 JSON 文件（- 为标准输入）设置额度策略
+  join <node_id> <raft_address> [http_address]
+                                      节点加入集群并登记其 HTTP 地址
+  remove <node_id>                    从集群移除节点
+  status                              查看 Raft 状态
+`
+
+type cli struct {
+	c      *client.Client
+	output string
+	// trusted 为带外核对过的节点身份地址，校验签名时只接受这些签名者
+	trusted []string
+}
+
+func main() {
+	fs := flag.NewFlagSet("ledgerctl", flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	nodes := fs.String("node", "127.0.0.1:8101", "comma separated node addresses")
+	keystoreDir := fs.String("keystore", "./keystore", "keystore directory")
+	output := fs.String("output", "table", "output format: table|json")
+	chainID := fs.String("chain", "", "chain id used for signing (default: read from node)")
+	consistency := fs.String("consistency", "", "read consistency for account queries: linearizable|leader|stale")
+	trusted := fs.String("trusted", "", "comma separated trusted node identity addresses")
+	_ = fs.Parse(os.Args[1:])
+
+	args := fs.Args()
+	if len(args) == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *output != "table" && *output != "json" {
+		fatalf("unknown output format: %s", *output)
+	}
+
+	ks, err := client.NewKeystore(*keystoreDir)
+	if err != nil {
+		fatalf("open keystore: %v", err)
+	}
+	c := client.New(strings.Split(*nodes, ",")...)
+	c.Keystore = ks
+	c.ChainID = *chainID
+	c.ReadConsistency = *consistency
+
+	app := &cli{c: c, output: *output}
+	if *trusted != "" {
+		app.trusted = strings.Split(*trusted, ",")
+	}
+	if err := app.run(args[0], args[1:]); err != nil {
+		fatalf("%s: %v", args[0], err)
+	}
+}
+
+func (a *cli) run(cmd string, args []string) error {
+	switch cmd {
+	case "keygen":
+		addr, err := a.c.Keystore.Generate()
+		if err != nil {
+			return err
+		}
+		return a.print(map[string]interface{}{"address": addr})
+	case "keys":
+		addrs, err := a.c.Keystore.List()
+		if err != nil {
+			return err
+		}
+		rows := make([]map[string]interface{}, 0, len(addrs))
+		for _, addr := range addrs {
+			rows = append(rows, map[string]interface{}{"address": addr})
+		}
+		return a.printRows([]string{"address"}, rows)
+	case "import":
+		if err := need(args, 1); err != nil {
+			return err
+		}
+		addr, err := a.c.Keystore.Import(args[0])
+		if err != nil {
+			return err
+		}
+		return a.print(map[string]interface{}{"address": addr})
+	case "register":
+		res, err := a.c.Register()
+		if err != nil {
+			return err
+		}
+		return a.print(map[string]interface{}{"address": res.Address, "role": res.Role})
+	case "account":
+		if err := need(args, 1); err != nil {
+			return err
+		}
+		acc, err := a.c.GetAccount(args[0])
+		if err != nil {
+			return err
+		}
+		return a.print(acc)
+	case "mint", "transfer":
+		if err := need(args, 3); err != nil {
+			return err
+		}
+		amount, err := strconv.ParseUint(args[2], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid amount: %v", err)
+		}
+		txType := client.TxTypeTransfer
+		if cmd == "mint" {
+			txType = client.TxTypeMint
+		}
+		receipt, err := a.c.SendTransaction(txType, args[0], args[1], amount)
+		if err != nil {
+			return err
+		}
+		return a.print(receipt)
+	case "burn":
+		if err := need(args, 2); err != nil {
+			return err
+		}
+		amount, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid amount: %v", err)
+		}
+		receipt, err := a.c.Burn(args[0], amount)
+		if err != nil {
+			return err
+		}
+		return a.print(receipt)
+	case "freeze", "unfreeze":
+		if err := need(args, 2); err != nil {
+			return err
+		}
+		txType := client.TxTypeFreeze
+		if cmd == "unfreeze" {
+			txType = client.TxTypeUnfreeze
+		}
+		receipt, err := a.c.SendTransaction(txType, args[0], args[1], 0)
+		if err != nil {
+			return err
+		}
+		return a.print(receipt)
+	case "promote", "demote":
+		if err := need(args, 2); err != nil {
+			return err
+		}
+		var res *client.RoleResult
+		var err error
+		if cmd == "promote" {
+			res, err = a.c.Promote(args[0], args[1])
+		} else {
+			res, err = a.c.Demote(args[0], args[1])
+		}
+		if err != nil {
+			return err
+		}
+		return a.print(res)
+	case "query":
+		if err := need(args, 1); err != nil {
+			return err
+		}
+		res, err := a.c.QueryTransactions(args[0])
+		if err != nil {
+			return err
+		}
+		if a.output == "json" {
+			return a.print(res)
+		}
+		rows := make([]map[string]interface{}, 0, len(res.Transactions))
+		for _, r := range res.Transactions {
+			rows = append(rows, map[string]interface{}{
+				"index": r.Index, "type": r.Type, "sender": r.Sender,
+				"receiver": r.Receiver, "amount": r.Amount, "nonce": r.Nonce,
+			})
+		}
+		return a.printRows([]string{"index", "type", "sender", "receiver", "amount", "nonce"}, rows)
+	case "history":
+		return a.history(args)
+	case "receipt":
+		if err := need(args, 1); err != nil {
+			return err
+		}
+		receipt, err := a.c.GetReceipt(args[0])
+		if err != nil {
+			return err
+		}
+		return a.print(receipt)
+	case "audit":
+		if err := need(args, 1); err != nil {
+			return err
+		}
+		idx, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid index: %v", err)
+		}
+		e, err := a.c.AuditEntry(idx)
+		if err != nil {
+			return err
+		}
+		return a.print(entryView(e))
+	case "audit-range":
+		if err := need(args, 1); err != nil {
+			return err
+		}
+		fromTime, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid from_time: %v", err)
+		}
+		var toTime int64
+		if len(args) > 1 {
+			if toTime, err = strconv.ParseInt(args[1], 10, 64); err != nil {
+				return fmt.Errorf("invalid to_time: %v", err)
+			}
+		}
+		return a.auditRange(fromTime, toTime)
+	case "prove":
+		if err := need(args, 1); err != nil {
+			return err
+		}
+		idx, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid index: %v", err)
+		}
+		var treeSize uint64
+		if len(args) > 1 {
+			if treeSize, err = strconv.ParseUint(args[1], 10, 64); err != nil {
+				return fmt.Errorf("invalid tree size: %v", err)
+			}
+		}
+		p, err := a.c.GetAuditProof(idx, treeSize)
+		if err != nil {
+			return err
+		}
+		if err := p.Verify(a.trusted); err != nil {
+			return err
+		}
+		return a.print(map[string]interface{}{
+			"index": p.Index, "tree_size": p.TreeSize, "root": p.Root,
+			"signer": p.Signer, "status": "verified",
+		})
+	case "consistency":
+		if err := need(args, 2); err != nil {
+			return err
+		}
+		oldSize, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid old size: %v", err)
+		}
+		var newSize uint64
+		if len(args) > 2 {
+			if newSize, err = strconv.ParseUint(args[2], 10, 64); err != nil {
+				return fmt.Errorf("invalid new size: %v", err)
+			}
+		}
+		p, err := a.c.GetConsistencyProof(oldSize, newSize)
+		if err != nil {
+			return err
+		}
+		if err := p.Verify(args[1], a.trusted); err != nil {
+			return err
+		}
+		return a.print(map[string]interface{}{
+			"old_size": p.OldSize, "new_size": p.NewSize, "new_root": p.NewRoot,
+			"signer": p.Signer, "status": "verified",
+		})
+	case "audit-head":
+		head, err := a.c.AuditHead()
+		if err != nil {
+			return err
+		}
+		return a.print(head)
+	case "checkpoints":
+		var from uint64
+		if len(args) > 0 {
+			n, err := strconv.ParseUint(args[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid index: %v", err)
+			}
+			from = n
+		}
+		return a.checkpoints(from)
+	case "audit-export":
+		from := uint64(1)
+		if len(args) > 0 {
+			n, err := strconv.ParseUint(args[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid index: %v", err)
+			}
+			from = n
+		}
+		return a.c.ExportAudit(from, os.Stdout)
+	case "verify":
+		n, err := a.verifyChain()
+		if err != nil {
+			return err
+		}
+		return a.print(map[string]interface{}{"verified_entries": n, "status": "ok"})
+	case "audit-verify":
+		if err := need(args, 1); err != nil {
+			return err
+		}
+		var p *client.AuditVerifyProgress
+		var err error
+		if len(args) > 1 && args[1] == "start" {
+			p, err = a.c.StartAuditVerify(args[0])
+		} else {
+			p, err = a.c.AuditVerifyStatus(args[0])
+		}
+		if err != nil {
+			return err
+		}
+		return a.print(p)
+	case "audit-clear-divergence":
+		if err := need(args, 1); err != nil {
+			return err
+		}
+		d, err := a.c.ClearAuditDivergence(args[0])
+		if err != nil {
+			return err
+		}
+		if d == nil {
+			fmt.Println("no divergence recorded")
+			return nil
+		}
+		return a.print(d)
+	case "supply":
+		sup, err := a.c.Supply(len(args) > 0 && args[0] == "verify")
+		if err != nil {
+			return err
+		}
+		return a.print(sup)
+	case "policy":
+		addr := ""
+		if len(args) > 0 {
+			addr = args[0]
+		}
+		info, err := a.c.GetPolicy(addr)
+		if err != nil {
+			return err
+		}
+		return a.print(info)
+	case "set-policy":
+		if err := need(args, 2); err != nil {
+			return err
+		}
+		policy, err := readPolicy(args[1])
+		if err != nil {
+			return err
+		}
+		r, err := a.c.SetPolicy(args[0], policy)
+		if err != nil {
+			return err
+		}
+		return a.print(r)
+	case "join":
+		if err := need(args, 2); err != nil {
+			return err
+		}
+		httpAddr := ""
+		if len(args) > 2 {
+			httpAddr = args[2]
+		}
+		if err := a.c.Join(args[0], args[1], httpAddr); err != nil {
+			return err
+		}
+		return a.print(map[string]interface{}{"status": "ok"})
+	case "remove":
+		if err := need(args, 1); err != nil {
+			return err
+		}
+		if err := a.c.Remove(args[0]); err != nil {
+			return err
+		}
+		return a.print(map[string]interface{}{"status": "ok"})
+	case "status":
+		st, err := a.c.RaftStatus()
+		if err != nil {
+			return err
+		}
+		return a.print(st)
+	default:
+		return fmt.Errorf("unknown command, run ledgerctl -h for usage")
+	}
+}
+
+// history 解析子命令参数并分页查询账户流水。
+func (a *cli) history(args []string) error {
+	if err := need(args, 2); err != nil {
+		return err
+	}
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	txType := fs.Int("type", -1, "transaction type filter")
+	counterparty := fs.String("counterparty", "", "counterparty address filter")
+	from := fs.Uint64("from", 0, "first audit index")
+	to := fs.Uint64("to", 0, "last audit index")
+	since := fs.Int64("since", 0, "earliest transaction time (unix seconds)")
+	until := fs.Int64("until", 0, "latest transaction time (unix seconds)")
+	cursor := fs.Uint64("cursor", 0, "cursor returned by previous page")
+	limit := fs.Int("limit", 0, "page size")
+	if err := fs.Parse(args[2:]); err != nil {
+		return err
+	}
+	opts := client.HistoryOptions{
+		Counterparty: *counterparty,
+		FromIndex:    *from,
+		ToIndex:      *to,
+		FromTime:     *since,
+		ToTime:       *until,
+		Cursor:       *cursor,
+		Limit:        *limit,
+	}
+	if *txType >= 0 {
+		t := client.TxType(*txType)
+		opts.Type = &t
+	}
+	page, err := a.c.AccountHistory(args[0], args[1], opts)
+	if err != nil {
+		return err
+	}
+	if a.output == "json" {
+		return a.print(page)
+	}
+	rows := make([]map[string]interface{}, 0, len(page.Transactions))
+	for _, r := range page.Transactions {
+		rows = append(rows, map[string]interface{}{
+			"index": r.Index, "time": r.Timestamp, "type": r.Type, "sender": r.Sender,
+			"receiver": r.Receiver, "amount": r.Amount, "nonce": r.Nonce,
+		})
+	}
+	if err := a.printRows([]string{"index", "time", "type", "sender", "receiver", "amount", "nonce"}, rows); err != nil {
+		return err
+	}
+	if page.NextCursor != 0 {
+		fmt.Printf("next cursor: %d\n", page.NextCursor)
+	}
+	return nil
+}
+
+// checkpoints 拉取检查点，校验签名及签名者在 -trusted 中，并与当前节点对应索引的审计条目哈希比对。
+func (a *cli) checkpoints(from uint64) error {
+	var rows []map[string]interface{}
+	for {
+		page, err := a.c.Checkpoints(from, 1000)
+		if err != nil {
+			return err
+		}
+		for i := range page.Checkpoints {
+			cp := &page.Checkpoints[i]
+			status := "ok"
+			if err := audit.VerifyCheckpoint(cp); err != nil {
+				status = err.Error()
+			} else if !a.isTrusted(cp.Signer) {
+				status = "untrusted signer"
+			} else if e, err := a.c.AuditEntry(cp.Index); err != nil {
+				status = err.Error()
+			} else if hex.EncodeToString(e.EntryHash[:]) != cp.LastHash {
+				status = "diverged"
+			}
+			rows = append(rows, map[string]interface{}{
+				"index": cp.Index, "term": cp.Term, "timestamp": cp.Timestamp,
+				"last_hash": cp.LastHash, "signer": cp.Signer, "status": status,
+			})
+		}
+		if page.NextFrom == 0 {
+			break
+		}
+		from = page.NextFrom
+	}
+	return a.printRows([]string{"index", "term", "timestamp", "last_hash", "signer", "status"}, rows)
+}
+
+// isTrusted 判断签名者是否在 -trusted 指定的节点身份中。
+func (a *cli) isTrusted(signer string) bool {
+	for _, t := range a.trusted {
+		if t == signer {
+			return true
+		}
+	}
+	return false
+}
+
+// auditRange 分页列出时间范围内的审计条目。
+func (a *cli) auditRange(fromTime, toTime int64) error {
+	var rows []map[string]interface{}
+	from := uint64(1)
+	for from != 0 {
+		page, err := a.c.AuditEntriesByTime(fromTime, toTime, from, 1000)
+		if err != nil {
+			return err
+		}
+		for i := range page.Entries {
+			e := &page.Entries[i]
+			rows = append(rows, map[string]interface{}{
+				"index": e.Index, "time": e.Timestamp, "term": e.Term,
+				"raft_index": e.RaftIndex, "entry_hash": hex.EncodeToString(e.EntryHash[:]),
+			})
+		}
+		from = page.NextFrom
+	}
+	return a.printRows([]string{"index", "time", "term", "raft_index", "entry_hash"}, rows)
+}
+
+// verifyChain 分页拉取审计条目并在本地重算哈希链。
+func (a *cli) verifyChain() (uint64, error) {
+	var prevHash [32]byte
+	var verified uint64
+	from := uint64(1)
+	for from != 0 {
+		page, err := a.c.AuditEntries(from, 1000)
+		if err != nil {
+			return verified, err
+		}
+		for i := range page.Entries {
+			e := &page.Entries[i]
+			want := verified + 1
+			if e.Index != want {
+				return verified, fmt.Errorf("audit entry index mismatch: want %d got %d", want, e.Index)
+			}
+			if e.PrevHash != prevHash {
+				return verified, fmt.Errorf("audit chain broken at %d: prevHash mismatch", want)
+			}
+			if audit.EntryHash(e) != e.EntryHash {
+				return verified, fmt.Errorf("audit chain broken at %d: entryHash mismatch", want)
+			}
+			prevHash = e.EntryHash
+			verified++
+		}
+		from = page.NextFrom
+	}
+	return verified, nil
+}
+
+func entryView(e *client.Entry) map[string]interface{} {
+	view := map[string]interface{}{
+		"index":      e.Index,
+		"version":    e.Version,
+		"prev_hash":  hex.EncodeToString(e.PrevHash[:]),
+		"entry_hash": hex.EncodeToString(e.EntryHash[:]),
+	}
+	if audit.HasEntryMeta(e.Version) {
+		view["timestamp"] = e.Timestamp
+		view["term"] = e.Term
+		view["raft_index"] = e.RaftIndex
+	}
+	if tx, err := audit.DecodeTransaction(e.TxBytes); err == nil {
+		b, _ := json.Marshal(tx)
+		view["tx"] = string(b)
+	} else {
+		view["tx"] = hex.EncodeToString(e.TxBytes)
+	}
+	return view
+}
+
+// print 按输出模式打印单个对象；table 模式下按 key 排序输出两列。
+func (a *cli) print(v interface{}) error {
+	if a.output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	fields, err := toMap(v)
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s\t%v\n", strings.ToUpper(k), fields[k])
+	}
+	return w.Flush()
+}
+
+// printRows 按输出模式打印多行记录。
+func (a *cli) printRows(columns []string, rows []map[string]interface{}) error {
+	if a.output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	header := make([]string, len(columns))
+	for i, col := range columns {
+		header[i] = strings.ToUpper(col)
+	}
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		cells := make([]string, len(columns))
+		for i, col := range columns {
+			cells[i] = fmt.Sprint(row[col])
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	return w.Flush()
+}
+
+func toMap(v interface{}) (map[string]interface{}, error) {
+	if m, ok := v.(map[string]interface{}); ok {
+		return m, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]interface{}{}
+	// 保留整数原样输出，避免大数被格式化为科学计数法
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// readPolicy 从文件或标准输入读取额度策略，拒绝未知字段以免拼写错误被静默忽略
+func readPolicy(path string) (*client.Policy, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var p client.Policy
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("parse policy: %w", err)
+	}
+	return &p, nil
+}
+
+func need(args []string, n int) error {
+	if len(args) < n {
+		return fmt.Errorf("expected %d arguments, got %d", n, len(args))
+	}
+	return nil
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "ledgerctl: "+format+"\n", args...)
+	os.Exit(1)
+}
@@ -2,19 +2,44 @@ package config
 
 import (
 	"errors"
+	"net"
 	"os"
+	"path/filepath"
+	"strconv"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
+// DefaultChainID 为未配置 chain_id 时使用的网络标识，同一集群的节点必须一致。
+const DefaultChainID = "ledger-dev"
+
+// DefaultCheckpointInterval 为 leader 发布审计检查点的默认周期。
+const DefaultCheckpointInterval = 30 * time.Second
+
 type Config struct {
-	NodeID    string   `yaml:"node_id"`
-	DataDir   string   `yaml:"data_dir"`
-	HTTPPort  int      `yaml:"http_port"`
-	RaftDir   string   `yaml:"raft_dir"`
-	RaftBind  string   `yaml:"raft_bind"`
-	RaftPeers []string `yaml:"raft_peers"`
-	RaftBootstrap bool `yaml:"raft_bootstrap"`
+	NodeID        string   `yaml:"node_id"`
+	DataDir       string   `yaml:"data_dir"`
+	HTTPPort      int      `yaml:"http_port"`
+	RaftDir       string   `yaml:"raft_dir"`
+	RaftBind      string   `yaml:"raft_bind"`
+	RaftPeers     []string `yaml:"raft_peers"`
+	RaftBootstrap bool     `yaml:"raft_bootstrap"`
+	ChainID       string   `yaml:"chain_id"`
+	// 其它节点访问本节点 HTTP 接口的地址，follower 据此把写请求转发给 leader，默认 <raft_bind 主机>:<http_port>
+	HTTPAdvertise string `yaml:"http_advertise"`
+	// 节点身份私钥文件，用于签名审计树根与检查点，默认 <raft_dir>/node.key。
+	// 生产环境应放在数据卷之外（如单独挂载的密钥目录），否则能篡改磁盘数据的人也能用它签名
+	IdentityKey string `yaml:"identity_key"`
+	// leader 发布签名检查点的周期，如 "30s"；链头未前进时不发布
+	CheckpointInterval time.Duration `yaml:"checkpoint_interval"`
+	// 后台全量审计校验每秒校验的条目数，默认 5000
+	AuditVerifyRate int `yaml:"audit_verify_rate"`
+	// 创世配置文件 genesis.json 的路径
+	Genesis string `yaml:"genesis"`
+	// 创世者地址（hex 公钥），未使用创世文件时以它作为只含创世者的创世配置；两者同时配置时必须一致。
+	// 首次引导集群（raft_bootstrap）时二者至少配置其一，注册接口不会产生创世者
+	Creator string `yaml:"creator"`
 }
 
 func Load(path string) (*Config, error) {
@@ -37,9 +62,25 @@ func Load(path string) (*Config, error) {
 	if cfg.RaftBind == "" {
 		cfg.RaftBind = "127.0.0.1:7000"
 	}
+	if cfg.ChainID == "" {
+		cfg.ChainID = DefaultChainID
+	}
+	if cfg.IdentityKey == "" {
+		cfg.IdentityKey = filepath.Join(cfg.RaftDir, "node.key")
+	}
+	if cfg.CheckpointInterval <= 0 {
+		cfg.CheckpointInterval = DefaultCheckpointInterval
+	}
 	if cfg.HTTPPort == 0 {
 		cfg.HTTPPort = 8080
 	}
+	if cfg.HTTPAdvertise == "" {
+		host, _, err := net.SplitHostPort(cfg.RaftBind)
+		if err != nil {
+			return nil, err
+		}
+		cfg.HTTPAdvertise = net.JoinHostPort(host, strconv.Itoa(cfg.HTTPPort))
+	}
 	if cfg.NodeID == "" {
 		return nil, errors.New("node_id is required")
 	}
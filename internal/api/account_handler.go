@@ -1,10 +1,14 @@
 package api
 
 import (
+	"encoding/hex"
+	"fmt"
 	"net/http"
 
-	"distributed_ledger_go/internal/types"
+	"distributed_ledger_go/internal/store"
+	"distributed_ledger_go/internal/txVerify"
 	"distributed_ledger_go/pkg/crypto"
+	"distributed_ledger_go/pkg/types"
 
 	"github.com/gin-gonic/gin"
 )
@@ -13,22 +17,26 @@ type promoteRequest struct {
 	CreatorAddress string `json:"creator_address"`
 	TargetAddress  string `json:"target_address"`
 	PrivateKey     string `json:"private_key"`
+	// Nonce 与 Signature 为离线签名方式：Signature 为对 txhash.RoleHash 的 ASN.1 DER 签名（hex），
+	// Nonce 为创世者当前 nonce + 1
+	Nonce     uint64 `json:"nonce"`
+	Signature string `json:"signature"`
 }
 
 func (s *Server) handleRegisterAccount(c *gin.Context) {
-	role := s.nextRole()
 	priv, addr, err := crypto.GenerateKeyPair()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err, nil)
 		return
 	}
-	if err := s.accountSvc.Register(addr, role); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	role, err := s.registerFn(addr)
+	if err != nil {
+		writeError(c, err, nil)
 		return
 	}
 	privHex, err := crypto.PrivateKeyToHex(priv)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err, nil)
 		return
 	}
 	c.JSON(http.StatusCreated, gin.H{
@@ -41,111 +49,91 @@ func (s *Server) handleRegisterAccount(c *gin.Context) {
 func (s *Server) handleGetAccount(c *gin.Context) {
 	addr := c.Param("address")
 	if addr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "address required"})
+		writeError(c, invalidRequest("address required"), nil)
+		return
+	}
+	if !s.checkReadConsistency(c) {
 		return
 	}
 	acc, err := s.accountSvc.GetAccount(addr)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		writeError(c, err, nil)
 		return
 	}
 	c.JSON(http.StatusOK, acc)
 }
 
-func (s *Server) nextRole() string {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	if !s.hasCreator {
-		s.hasCreator = true
-		return types.RoleCreator
-	}
-	return types.RoleUser
-}
-
 func (s *Server) handlePromoteAccount(c *gin.Context) {
-	var req promoteRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	if req.CreatorAddress == "" || req.TargetAddress == "" || req.PrivateKey == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "creator_address, target_address and private_key required"})
-		return
-	}
-	priv, err := crypto.HexToPrivateKey(req.PrivateKey)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	addrHex, err := crypto.PublicKeyToHex(&priv.PublicKey)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	if addrHex != req.CreatorAddress {
-		c.JSON(http.StatusForbidden, gin.H{"error": "private key does not match creator address"})
-		return
-	}
-	creator, err := s.accountSvc.GetAccount(req.CreatorAddress)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	if creator.Role != types.RoleCreator {
-		c.JSON(http.StatusForbidden, gin.H{"error": "only creator can promote"})
-		return
-	}
-	if _, err := s.accountSvc.GetAccount(req.TargetAddress); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	if err := s.accountSvc.GrantRole(req.TargetAddress, types.RoleAdmin); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	c.JSON(http.StatusOK, gin.H{"target": req.TargetAddress, "role": types.RoleAdmin})
+	s.handleChangeRole(c, types.RoleAdmin)
 }
 
 func (s *Server) handleDemoteAccount(c *gin.Context) {
+	s.handleChangeRole(c, types.RoleUser)
+}
+
+// handleChangeRole 由创世者调整目标账户角色。优先使用离线签名（nonce + signature），
+// 节点只校验签名而不接触私钥；private_key 方式保留兼容。
+func (s *Server) handleChangeRole(c *gin.Context, role string) {
 	var req promoteRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	if req.CreatorAddress == "" || req.TargetAddress == "" || req.PrivateKey == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "creator_address, target_address and private_key required"})
-		return
-	}
-	priv, err := crypto.HexToPrivateKey(req.PrivateKey)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	addrHex, err := crypto.PublicKeyToHex(&priv.PublicKey)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	if addrHex != req.CreatorAddress {
-		c.JSON(http.StatusForbidden, gin.H{"error": "private key does not match creator address"})
+		writeError(c, invalidRequest("%v", err), nil)
+		return
+	}
+	if req.CreatorAddress == "" || req.TargetAddress == "" {
+		writeError(c, invalidRequest("creator_address and target_address required"), nil)
+		return
+	}
+	var (
+		signer string
+		nonce  uint64
+	)
+	switch {
+	case req.Signature != "":
+		sig, err := hex.DecodeString(req.Signature)
+		if err != nil {
+			writeError(c, invalidRequest("invalid signature: not hex"), nil)
+			return
+		}
+		if err := s.validator.VerifyRoleSignature(req.CreatorAddress, req.TargetAddress, role, req.Nonce, sig); err != nil {
+			writeError(c, fmt.Errorf("%w: %v", txVerify.ErrInvalidSignature, err), nil)
+			return
+		}
+		signer, nonce = req.CreatorAddress, req.Nonce
+	case req.PrivateKey != "":
+		priv, err := crypto.HexToPrivateKey(req.PrivateKey)
+		if err != nil {
+			writeError(c, invalidRequest("%v", err), nil)
+			return
+		}
+		addrHex, err := crypto.PublicKeyToHex(&priv.PublicKey)
+		if err != nil {
+			writeError(c, err, nil)
+			return
+		}
+		if addrHex != req.CreatorAddress {
+			writeError(c, fmt.Errorf("%w: private key does not match creator address", txVerify.ErrPermissionDenied), nil)
+			return
+		}
+	default:
+		writeError(c, invalidRequest("signature or private_key required"), nil)
 		return
 	}
 	creator, err := s.accountSvc.GetAccount(req.CreatorAddress)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, err, nil)
 		return
 	}
 	if creator.Role != types.RoleCreator {
-		c.JSON(http.StatusForbidden, gin.H{"error": "only creator can demote"})
+		writeError(c, store.ErrNotCreator, nil)
 		return
 	}
 	if _, err := s.accountSvc.GetAccount(req.TargetAddress); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, err, nil)
 		return
 	}
-	if err := s.accountSvc.GrantRole(req.TargetAddress, types.RoleUser); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := s.setRoleFn(req.TargetAddress, role, signer, nonce); err != nil {
+		writeError(c, err, nil)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"target": req.TargetAddress, "role": types.RoleUser})
+	c.JSON(http.StatusOK, gin.H{"target": req.TargetAddress, "role": role})
 }
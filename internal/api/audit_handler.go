@@ -1,28 +1,346 @@
 package api
 
 import (
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
+	"sync"
+	"time"
+
+	"distributed_ledger_go/internal/txVerify"
+	"distributed_ledger_go/pkg/audit"
+	"distributed_ledger_go/pkg/types"
 
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
 func (s *Server) handleAuditEntry(c *gin.Context) {
 	indexStr := c.Param("index")
 	if indexStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "index required"})
+		writeError(c, invalidRequest("index required"), nil)
 		return
 	}
 	idx, err := strconv.ParseUint(indexStr, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid index: %v", err)})
+		writeError(c, invalidRequest("invalid index: %v", err), nil)
 		return
 	}
 	entry, err := s.auditSvc.GetEntry(idx)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		writeError(c, err, nil)
 		return
 	}
 	c.JSON(http.StatusOK, entry)
 }
+
+// handleAuditList 分页读取审计条目：from 为起始索引（默认 1），limit 为条数；
+// 返回的 next_from 为下一页起始索引，0 表示已到链头。
+func (s *Server) handleAuditList(c *gin.Context) {
+	from, err := parseUintQuery(c, "from")
+	if err != nil {
+		writeError(c, err, nil)
+		return
+	}
+	if from == 0 {
+		from = 1
+	}
+	limit := defaultAuditLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(c, invalidRequest("invalid limit: %s", v), nil)
+			return
+		}
+		if n > maxAuditLimit {
+			n = maxAuditLimit
+		}
+		limit = n
+	}
+	fromTime, toTime, err := parseTimeRange(c)
+	if err != nil {
+		writeError(c, err, nil)
+		return
+	}
+	lastIndex, _, err := s.auditSvc.Head()
+	if err != nil {
+		writeError(c, err, nil)
+		return
+	}
+	if fromTime != 0 || toTime != 0 {
+		// 条目时间单调不减，二分定位起点；没有时间戳的旧条目不参与时间过滤
+		if fromTime == 0 {
+			fromTime = 1
+		}
+		start, err := s.auditSvc.SearchByTime(fromTime)
+		if err != nil {
+			writeError(c, err, nil)
+			return
+		}
+		if start > from {
+			from = start
+		}
+	}
+	entries, err := s.auditSvc.ListRange(from, limit)
+	if err != nil {
+		writeError(c, err, nil)
+		return
+	}
+	next := uint64(0)
+	if n := len(entries); n > 0 && entries[n-1].Index < lastIndex {
+		next = entries[n-1].Index + 1
+	}
+	if toTime != 0 {
+		for i, e := range entries {
+			if e.Timestamp > toTime {
+				entries, next = entries[:i], 0
+				break
+			}
+		}
+	}
+	if entries == nil {
+		entries = []*types.Entry{}
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": entries, "next_from": next, "last_index": lastIndex})
+}
+
+// handleAuditHead 返回审计链最新索引与哈希。
+func (s *Server) handleAuditHead(c *gin.Context) {
+	lastIndex, lastHash, err := s.auditSvc.Head()
+	if err != nil {
+		writeError(c, err, nil)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"last_index": lastIndex, "last_hash": hex.EncodeToString(lastHash[:])})
+}
+
+// handleAuditExport 以 NDJSON 流式导出从 from（默认 1）到当前链头的审计条目，每行一个条目。
+func (s *Server) handleAuditExport(c *gin.Context) {
+	from, err := parseUintQuery(c, "from")
+	if err != nil {
+		writeError(c, err, nil)
+		return
+	}
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Status(http.StatusOK)
+	enc := json.NewEncoder(c.Writer)
+	written := 0
+	err = s.auditSvc.Iterate(from, func(e *types.Entry) error {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+		written++
+		if written%maxAuditLimit == 0 {
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		// 响应头已发送，只能以最后一行携带错误信息
+		_, code := errorStatus(err)
+		_ = enc.Encode(gin.H{"error": err.Error(), "code": code})
+	}
+	c.Writer.Flush()
+}
+
+// handleAuditProof 返回审计条目的 Merkle 包含性证明，以及由本节点身份密钥签名的树根。
+// 可选参数 tree_size 指定证明所针对的树大小，默认为当前链头。
+func (s *Server) handleAuditProof(c *gin.Context) {
+	idx, err := strconv.ParseUint(c.Param("index"), 10, 64)
+	if err != nil {
+		writeError(c, invalidRequest("invalid index: %v", err), nil)
+		return
+	}
+	treeSize, err := parseUintQuery(c, "tree_size")
+	if err != nil {
+		writeError(c, err, nil)
+		return
+	}
+	entry, err := s.auditSvc.GetEntry(idx)
+	if err != nil {
+		writeError(c, err, nil)
+		return
+	}
+	proof, size, root, err := s.auditSvc.InclusionProof(idx, treeSize)
+	if err != nil {
+		writeError(c, err, nil)
+		return
+	}
+	sig, signer, err := s.signFunc(audit.SignedRootPayload(size, root))
+	if err != nil {
+		writeError(c, err, nil)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"index":     idx,
+		"entry":     entry,
+		"tree_size": size,
+		"root":      hex.EncodeToString(root[:]),
+		"proof":     hashesToHex(proof),
+		"signer":    signer,
+		"signature": hex.EncodeToString(sig),
+	})
+}
+
+// handleAuditConsistency 返回审计树从 old_size 到 new_size 的一致性证明，
+// 新树根由本节点身份密钥签名；new_size 缺省为当前链头。
+func (s *Server) handleAuditConsistency(c *gin.Context) {
+	oldSize, err := parseUintQuery(c, "old_size")
+	if err != nil {
+		writeError(c, err, nil)
+		return
+	}
+	if oldSize == 0 {
+		writeError(c, invalidRequest("old_size required"), nil)
+		return
+	}
+	newSize, err := parseUintQuery(c, "new_size")
+	if err != nil {
+		writeError(c, err, nil)
+		return
+	}
+	proof, size, oldRoot, newRoot, err := s.auditSvc.ConsistencyProof(oldSize, newSize)
+	if err != nil {
+		writeError(c, err, nil)
+		return
+	}
+	sig, signer, err := s.signFunc(audit.SignedRootPayload(size, newRoot))
+	if err != nil {
+		writeError(c, err, nil)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"old_size":  oldSize,
+		"old_root":  hex.EncodeToString(oldRoot[:]),
+		"new_size":  size,
+		"new_root":  hex.EncodeToString(newRoot[:]),
+		"proof":     hashesToHex(proof),
+		"signer":    signer,
+		"signature": hex.EncodeToString(sig),
+	})
+}
+
+// handleAuditCheckpoints 分页返回 leader 签名的审计链检查点，from 为起始审计索引。
+func (s *Server) handleAuditCheckpoints(c *gin.Context) {
+	from, err := parseUintQuery(c, "from")
+	if err != nil {
+		writeError(c, err, nil)
+		return
+	}
+	limit := defaultAuditLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(c, invalidRequest("invalid limit: %s", v), nil)
+			return
+		}
+		if n > maxAuditLimit {
+			n = maxAuditLimit
+		}
+		limit = n
+	}
+	// 多取一条用于判断是否还有下一页
+	checkpoints, err := s.auditSvc.ListCheckpoints(from, limit+1)
+	if err != nil {
+		writeError(c, err, nil)
+		return
+	}
+	next := uint64(0)
+	if len(checkpoints) > limit {
+		next = checkpoints[limit].Index
+		checkpoints = checkpoints[:limit]
+	}
+	if checkpoints == nil {
+		checkpoints = []*types.Checkpoint{}
+	}
+	c.JSON(http.StatusOK, gin.H{"checkpoints": checkpoints, "next_from": next})
+}
+
+// requireAdmin 为运维接口的中间件：请求者须以 X-Requester-Address / X-Timestamp / X-Nonce / X-Signature 头部
+// 证明自己是管理员或创世者，签名校验与去重见 authenticateRequester。
+func (s *Server) requireAdmin(c *gin.Context) {
+	req, ok := parseSignedHeaders(c)
+	if !ok || !s.authenticateRequester(c, req) {
+		c.Abort()
+		return
+	}
+	requester, err := s.accountSvc.GetAccount(req.RequesterAddress)
+	if err != nil {
+		writeError(c, err, nil)
+		c.Abort()
+		return
+	}
+	if requester.Role != types.RoleAdmin && requester.Role != types.RoleCreator {
+		writeError(c, fmt.Errorf("%w: insufficient permission", txVerify.ErrPermissionDenied), nil)
+		c.Abort()
+	}
+}
+
+// maxRequestNonceLen 限制 X-Nonce 长度，防止去重表被超长值撑大
+const maxRequestNonceLen = 64
+
+// replayCache 记录有效期内已使用的签名请求 nonce。
+type replayCache struct {
+	mu   sync.Mutex
+	used map[string]int64
+}
+
+func newReplayCache() *replayCache {
+	return &replayCache{used: map[string]int64{}}
+}
+
+// consume 登记 key，有效期内已登记过时返回 false；同时清理已过期的记录。
+func (r *replayCache) consume(key string, timestamp int64) bool {
+	now := time.Now()
+	expiry := time.Unix(timestamp, 0).Add(querySignatureWindow).Unix()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for k, exp := range r.used {
+		if exp < now.Unix() {
+			delete(r.used, k)
+		}
+	}
+	if _, ok := r.used[key]; ok {
+		return false
+	}
+	r.used[key] = expiry
+	return true
+}
+
+// handleAuditVerifyStatus 返回后台全量审计校验的进度。
+func (s *Server) handleAuditVerifyStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, s.verifier.Progress())
+}
+
+// handleAuditVerifyStart 触发一次后台全量审计校验，已有校验在运行时返回 409。
+func (s *Server) handleAuditVerifyStart(c *gin.Context) {
+	if !s.verifier.StartFull() {
+		writeError(c, ErrVerifyRunning, gin.H{"progress": s.verifier.Progress()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"status": "started", "progress": s.verifier.Progress()})
+}
+
+// handleAuditDivergenceClear 供运维在修复本节点数据后清除持久化的分叉记录、恢复写入；只作用于接收请求的节点。
+func (s *Server) handleAuditDivergenceClear(c *gin.Context) {
+	d, err := s.auditSvc.ClearDivergence()
+	if err != nil {
+		writeError(c, err, nil)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "cleared", "divergence": d})
+}
+
+func hashesToHex(hashes [][32]byte) []string {
+	out := make([]string, len(hashes))
+	for i, h := range hashes {
+		out[i] = hex.EncodeToString(h[:])
+	}
+	return out
+}
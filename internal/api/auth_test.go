@@ -0,0 +1,56 @@
+package api
+
+import (
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"distributed_ledger_go/internal/txVerify"
+	"distributed_ledger_go/pkg/crypto"
+	"distributed_ledger_go/pkg/txhash"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthenticateRequesterBindsQueryAndRejectsReplay(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	priv, addr, err := crypto.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{validator: txVerify.NewValidator(nil, "test"), replay: newReplayCache()}
+	ts := time.Now().Unix()
+	signed := "/accounts/" + addr + "/transactions?limit=10"
+	sig, err := crypto.Sign(priv, txhash.RequestHash("test", addr, http.MethodGet, signed, ts, "n1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	send := func(uri string) int {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, uri, nil)
+		c.Request.Header.Set("X-Requester-Address", addr)
+		c.Request.Header.Set("X-Timestamp", strconv.FormatInt(ts, 10))
+		c.Request.Header.Set("X-Nonce", "n1")
+		c.Request.Header.Set("X-Signature", hex.EncodeToString(sig))
+		req, ok := parseSignedHeaders(c)
+		if ok && s.authenticateRequester(c, req) {
+			return http.StatusOK
+		}
+		return w.Code
+	}
+
+	// 改写查询参数后签名失效
+	if code := send("/accounts/" + addr + "/transactions?limit=500"); code != http.StatusUnauthorized {
+		t.Fatalf("tampered query: status %d, want 401", code)
+	}
+	if code := send(signed); code != http.StatusOK {
+		t.Fatalf("signed request: status %d, want 200", code)
+	}
+	if code := send(signed); code != http.StatusUnauthorized {
+		t.Fatalf("replayed request: status %d, want 401", code)
+	}
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"distributed_ledger_go/internal/service"
+	"distributed_ledger_go/internal/store"
+	"distributed_ledger_go/internal/txVerify"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 错误响应中的 code 字段，供客户端按类别分支处理；已发布的取值不再修改。
+const (
+	CodeInsufficientBalance = "insufficient_balance"
+	CodeNonceMismatch       = "nonce_mismatch"
+	CodeAccountExists       = "account_exists"
+	CodeAccountFrozen       = "account_frozen"
+	CodePermissionDenied    = "permission_denied"
+	CodeAccountNotFound     = "account_not_found"
+	CodeInvalidSignature    = "invalid_signature"
+	CodeChainMismatch       = "chain_mismatch"
+	CodeTxExpired           = "tx_expired"
+	CodeInvalidAmount       = "invalid_amount"
+	CodeUnknownTxType       = "unknown_tx_type"
+	CodeNotLeader           = "not_leader"
+	CodeTimeout             = "timeout"
+	CodeAuditDiverged       = "audit_diverged"
+	CodeGenesisMismatch     = "genesis_mismatch"
+	CodeInvalidReceiver     = "invalid_receiver"
+	CodeLimitExceeded       = "limit_exceeded"
+	CodeInvalidPolicy       = "invalid_policy"
+	CodeUnexpectedData      = "unexpected_data"
+	CodeInvalidReport       = "invalid_report"
+	CodeReceiptNotFound     = "receipt_not_found"
+	CodeEntryNotFound       = "entry_not_found"
+	CodeOutOfRange          = "out_of_range"
+	CodeInvalidRequest      = "invalid_request"
+	CodeUnauthenticated     = "unauthenticated"
+	CodeUnavailable         = "unavailable"
+	CodeVerifyRunning       = "audit_verify_running"
+	CodeSupplyMismatch      = "supply_mismatch"
+	CodeInternal            = "internal_error"
+)
+
+// API 层自身的请求错误，由 handler 包装具体原因后交给 writeError。
+var (
+	ErrInvalidRequest  = errors.New("invalid request")
+	ErrUnauthenticated = errors.New("unauthenticated")
+	ErrUnavailable     = errors.New("unavailable")
+	ErrVerifyRunning   = errors.New("audit verification already running")
+)
+
+// invalidRequest 包装请求参数错误，对应 400 与 invalid_request。
+func invalidRequest(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidRequest, fmt.Sprintf(format, args...))
+}
+
+// retryAfterSeconds 为 503 响应的 Retry-After，通常一次选举即可恢复
+const retryAfterSeconds = "1"
+
+type errorMapping struct {
+	err    error
+	status int
+	code   string
+}
+
+// errorMappings 按顺序匹配，未列出的错误视为内部错误。
+var errorMappings = []errorMapping{
+	{store.ErrInsufficientBalance, http.StatusConflict, CodeInsufficientBalance},
+	{store.ErrNonceMismatch, http.StatusConflict, CodeNonceMismatch},
+	{store.ErrAccountExists, http.StatusConflict, CodeAccountExists},
+	{store.ErrFrozen, http.StatusForbidden, CodeAccountFrozen},
+	{store.ErrCreatorFixed, http.StatusForbidden, CodePermissionDenied},
+	{txVerify.ErrPermissionDenied, http.StatusForbidden, CodePermissionDenied},
+	{store.ErrNotCreator, http.StatusForbidden, CodePermissionDenied},
+	{store.ErrAccountNotFound, http.StatusNotFound, CodeAccountNotFound},
+	{txVerify.ErrInvalidSignature, http.StatusUnauthorized, CodeInvalidSignature},
+	{txVerify.ErrChainMismatch, http.StatusBadRequest, CodeChainMismatch},
+	{txVerify.ErrTxExpired, http.StatusBadRequest, CodeTxExpired},
+	{txVerify.ErrInvalidAmount, http.StatusBadRequest, CodeInvalidAmount},
+	{store.ErrUnknownTxType, http.StatusBadRequest, CodeUnknownTxType},
+	{txVerify.ErrInvalidReceiver, http.StatusBadRequest, CodeInvalidReceiver},
+	{store.ErrGenesisMismatch, http.StatusConflict, CodeGenesisMismatch},
+	{store.ErrSupplyOverflow, http.StatusBadRequest, CodeInvalidAmount},
+	{store.ErrSupplyMismatch, http.StatusConflict, CodeSupplyMismatch},
+	{txVerify.ErrLimitExceeded, http.StatusForbidden, CodeLimitExceeded},
+	{store.ErrInvalidPolicy, http.StatusBadRequest, CodeInvalidPolicy},
+	{txVerify.ErrUnexpectedData, http.StatusBadRequest, CodeUnexpectedData},
+	{service.ErrNotLeader, http.StatusServiceUnavailable, CodeNotLeader},
+	{service.ErrTimeout, http.StatusServiceUnavailable, CodeTimeout},
+	{service.ErrAuditDiverged, http.StatusServiceUnavailable, CodeAuditDiverged},
+	{service.ErrInvalidReport, http.StatusUnauthorized, CodeInvalidReport},
+	{store.ErrReceiptNotFound, http.StatusNotFound, CodeReceiptNotFound},
+	{store.ErrEntryNotFound, http.StatusNotFound, CodeEntryNotFound},
+	{store.ErrOutOfRange, http.StatusBadRequest, CodeOutOfRange},
+	{ErrInvalidRequest, http.StatusBadRequest, CodeInvalidRequest},
+	{ErrUnauthenticated, http.StatusUnauthorized, CodeUnauthenticated},
+	{ErrUnavailable, http.StatusServiceUnavailable, CodeUnavailable},
+	{ErrVerifyRunning, http.StatusConflict, CodeVerifyRunning},
+}
+
+// errorStatus 返回错误对应的 HTTP 状态码与错误码。
+func errorStatus(err error) (int, string) {
+	for _, m := range errorMappings {
+		if errors.Is(err, m.err) {
+			return m.status, m.code
+		}
+	}
+	return http.StatusInternalServerError, CodeInternal
+}
+
+// writeError 按错误类型写回状态码与 {"error", "code"}，extra 中的字段一并返回；503 时附带 Retry-After。
+func writeError(c *gin.Context, err error, extra gin.H) {
+	status, code := errorStatus(err)
+	resp := gin.H{"error": err.Error(), "code": code}
+	for k, v := range extra {
+		resp[k] = v
+	}
+	if status == http.StatusServiceUnavailable {
+		c.Header("Retry-After", retryAfterSeconds)
+	}
+	c.JSON(status, resp)
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"distributed_ledger_go/internal/store"
+)
+
+func TestErrorStatus(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{fmt.Errorf("%w: abc", store.ErrReceiptNotFound), http.StatusNotFound, CodeReceiptNotFound},
+		{fmt.Errorf("%w: 7", store.ErrEntryNotFound), http.StatusNotFound, CodeEntryNotFound},
+		{fmt.Errorf("%w: tree size 9 exceeds audit log size 3", store.ErrOutOfRange), http.StatusBadRequest, CodeOutOfRange},
+		{invalidRequest("invalid limit: %s", "x"), http.StatusBadRequest, CodeInvalidRequest},
+		{fmt.Errorf("join %w", ErrUnavailable), http.StatusServiceUnavailable, CodeUnavailable},
+		{fmt.Errorf("%w: supply 10, burn 20", store.ErrSupplyMismatch), http.StatusConflict, CodeSupplyMismatch},
+		// 存储故障不能被误报为 404
+		{errors.New("badger: read failed"), http.StatusInternalServerError, CodeInternal},
+	}
+	for _, tc := range cases {
+		status, code := errorStatus(tc.err)
+		if status != tc.status || code != tc.code {
+			t.Errorf("errorStatus(%v) = %d %s, want %d %s", tc.err, status, code, tc.status, tc.code)
+		}
+	}
+}
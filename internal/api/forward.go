@@ -0,0 +1,66 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	"distributed_ledger_go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// headerForwarded 标记已被 follower 转发过的请求，避免在 leader 变更期间来回转发
+const headerForwarded = "X-Ledger-Forwarded"
+
+// rejectDiverged 为业务写接口的中间件：本节点审计链已分叉时直接拒绝，不再转发给 leader，
+// 直到运维清除分叉记录。
+func (s *Server) rejectDiverged(c *gin.Context) {
+	if d := s.auditSvc.Divergence(); d != nil {
+		writeError(c, fmt.Errorf("%w at index %d, writes disabled", service.ErrAuditDiverged, d.Index), nil)
+		c.Abort()
+	}
+}
+
+// forwardToLeader 为写接口的中间件：本节点不是 leader 时，把请求原样代理到 leader 的 HTTP 地址。
+func (s *Server) forwardToLeader(c *gin.Context) {
+	if s.leaderFunc == nil {
+		return
+	}
+	leader, raftLeader, isLeader := s.leaderFunc()
+	if isLeader {
+		return
+	}
+	s.proxyToLeader(c, leader, raftLeader)
+}
+
+// proxyToLeader 把请求代理给 leader 并终止本地处理链；leader 的 HTTP 地址未知或请求已被转发过时返回 503。
+// leader 的 HTTP 地址尚未登记（如 leader 刚当选）时，X-Raft-Leader 退回其 Raft 地址并在响应体的 raft_leader 中注明，
+// 加入集群等运维操作可据此定位 leader。
+func (s *Server) proxyToLeader(c *gin.Context, leader, raftLeader string) {
+	if leader == "" || c.GetHeader(headerForwarded) != "" {
+		body := gin.H{"error": "not leader", "code": CodeNotLeader}
+		hint := leader
+		if hint == "" && raftLeader != "" {
+			hint = raftLeader
+			body["raft_leader"] = raftLeader
+		}
+		if hint != "" {
+			c.Header("X-Raft-Leader", hint)
+		}
+		c.Header("Retry-After", retryAfterSeconds)
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, body)
+		return
+	}
+	target := &url.URL{Scheme: "http", Host: leader}
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		c.Header("X-Raft-Leader", leader)
+		c.Header("Retry-After", retryAfterSeconds)
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "forward to leader: " + err.Error(), "code": CodeNotLeader})
+	}
+	c.Request.Header.Set(headerForwarded, "1")
+	proxy.ServeHTTP(c.Writer, c.Request)
+	c.Abort()
+}
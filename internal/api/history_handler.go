@@ -0,0 +1,189 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"distributed_ledger_go/internal/store"
+	"distributed_ledger_go/internal/txVerify"
+	"distributed_ledger_go/pkg/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 500
+)
+
+// handleAccountTransactions 分页查询账户流水。
+// 请求者通过 X-Requester-Address / X-Timestamp / X-Nonce / X-Signature 头部以签名方式证明身份，
+// 签名载荷为 txhash.RequestHash，路径含查询参数。
+func (s *Server) handleAccountTransactions(c *gin.Context) {
+	address := c.Param("address")
+	filter, err := parseHistoryFilter(c)
+	if err != nil {
+		writeError(c, err, nil)
+		return
+	}
+	// 先完成一致性读（可能转发给 leader），再消耗签名 nonce，读屏障失败时客户端可以原样重试
+	if !s.checkReadConsistency(c) {
+		return
+	}
+	requester, ok := s.signedRequester(c)
+	if !ok {
+		return
+	}
+	allowed, err := s.canViewHistory(requester, address)
+	if err != nil {
+		writeError(c, err, nil)
+		return
+	}
+	if !allowed {
+		writeError(c, fmt.Errorf("%w: insufficient permission", txVerify.ErrPermissionDenied), nil)
+		return
+	}
+	records, next, err := s.txSvc.ListHistory(address, filter)
+	if err != nil {
+		writeError(c, err, nil)
+		return
+	}
+	if records == nil {
+		records = []*types.HistoryRecord{}
+	}
+	c.JSON(http.StatusOK, gin.H{"transactions": records, "next_cursor": next})
+}
+
+// signedRequester 从签名头部校验请求者身份并返回其账户，失败时直接写回错误响应。
+func (s *Server) signedRequester(c *gin.Context) (*types.Account, bool) {
+	req, ok := parseSignedHeaders(c)
+	if !ok || !s.authenticateRequester(c, req) {
+		return nil, false
+	}
+	requester, err := s.accountSvc.GetAccount(req.RequesterAddress)
+	if err != nil {
+		writeError(c, err, nil)
+		return nil, false
+	}
+	return requester, true
+}
+
+// parseSignedHeaders 读取 X-Requester-Address / X-Timestamp / X-Nonce / X-Signature 头部，失败时直接写回错误响应。
+func parseSignedHeaders(c *gin.Context) (queryRequest, bool) {
+	ts, err := strconv.ParseInt(c.GetHeader("X-Timestamp"), 10, 64)
+	if err != nil {
+		writeError(c, invalidRequest("invalid X-Timestamp"), nil)
+		return queryRequest{}, false
+	}
+	req := queryRequest{
+		RequesterAddress: c.GetHeader("X-Requester-Address"),
+		Timestamp:        ts,
+		Nonce:            c.GetHeader("X-Nonce"),
+		Signature:        c.GetHeader("X-Signature"),
+	}
+	if req.RequesterAddress == "" || req.Signature == "" {
+		writeError(c, fmt.Errorf("%w: X-Requester-Address and X-Signature required", ErrUnauthenticated), nil)
+		return queryRequest{}, false
+	}
+	return req, true
+}
+
+// canViewHistory 判断请求者能否查看目标地址流水：
+// 本人总是可以；创世者可审计全网；管理员可审查普通用户。
+func (s *Server) canViewHistory(requester *types.Account, target string) (bool, error) {
+	if requester.Address == target || requester.Role == types.RoleCreator {
+		return true, nil
+	}
+	if requester.Role != types.RoleAdmin {
+		return false, nil
+	}
+	targetAcc, err := s.accountSvc.GetAccount(target)
+	if err != nil {
+		return false, err
+	}
+	return targetAcc.Role == "" || targetAcc.Role == types.RoleUser, nil
+}
+
+// parseHistoryFilter 解析 type、counterparty、from_index、to_index、cursor、limit 查询参数。
+func parseHistoryFilter(c *gin.Context) (store.HistoryFilter, error) {
+	f := store.HistoryFilter{
+		Counterparty: c.Query("counterparty"),
+		Limit:        defaultHistoryLimit,
+	}
+	if v := c.Query("type"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return f, invalidRequest("invalid type: %v", err)
+		}
+		txType := types.TxType(n)
+		f.Type = &txType
+	}
+	var err error
+	if f.FromIndex, err = parseUintQuery(c, "from_index"); err != nil {
+		return f, err
+	}
+	if f.ToIndex, err = parseUintQuery(c, "to_index"); err != nil {
+		return f, err
+	}
+	if f.Cursor, err = parseUintQuery(c, "cursor"); err != nil {
+		return f, err
+	}
+	if f.FromTime, f.ToTime, err = parseTimeRange(c); err != nil {
+		return f, err
+	}
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return f, invalidRequest("invalid limit: %s", v)
+		}
+		if n > maxHistoryLimit {
+			n = maxHistoryLimit
+		}
+		f.Limit = n
+	}
+	return f, nil
+}
+
+func parseUintQuery(c *gin.Context, name string) (uint64, error) {
+	v := c.Query(name)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return 0, invalidRequest("invalid %s: %v", name, err)
+	}
+	return n, nil
+}
+
+// parseTimeRange 解析 from_time、to_time（Unix 秒，闭区间）；超出 int64 范围或为负数时返回错误。
+func parseTimeRange(c *gin.Context) (int64, int64, error) {
+	from, err := parseTimeQuery(c, "from_time")
+	if err != nil {
+		return 0, 0, err
+	}
+	to, err := parseTimeQuery(c, "to_time")
+	if err != nil {
+		return 0, 0, err
+	}
+	if to != 0 && from > to {
+		return 0, 0, invalidRequest("from_time must not be after to_time")
+	}
+	return from, to, nil
+}
+
+func parseTimeQuery(c *gin.Context, name string) (int64, error) {
+	v := c.Query(name)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, invalidRequest("invalid %s: %v", name, err)
+	}
+	if n < 0 {
+		return 0, invalidRequest("invalid %s: must not be negative", name)
+	}
+	return n, nil
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"distributed_ledger_go/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleGetPolicy 返回当前额度策略（未设置时为 null）；带 address 参数时同时返回该账户生效的限制与使用量。
+func (s *Server) handleGetPolicy(c *gin.Context) {
+	if !s.checkReadConsistency(c) {
+		return
+	}
+	policy, err := s.txSvc.Policy()
+	if err != nil {
+		writeError(c, err, nil)
+		return
+	}
+	resp := gin.H{"policy": policy}
+	if addr := c.Query("address"); addr != "" {
+		acc, err := s.accountSvc.GetAccount(addr)
+		if err != nil {
+			writeError(c, err, nil)
+			return
+		}
+		usage, err := s.txSvc.Usage(addr)
+		if err != nil {
+			writeError(c, err, nil)
+			return
+		}
+		resp["address"] = addr
+		resp["usage"] = usage
+		if policy != nil {
+			resp["limits"] = policy.LimitsFor(acc.Address, acc.Role)
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// handleSupply 返回全网发行量；verify=true 时在同一快照中累加所有账户余额，校验二者相等。
+func (s *Server) handleSupply(c *gin.Context) {
+	if !s.checkReadConsistency(c) {
+		return
+	}
+	if c.Query("verify") != "true" {
+		sup, err := s.txSvc.Supply()
+		if err != nil {
+			writeError(c, err, nil)
+			return
+		}
+		c.JSON(http.StatusOK, sup)
+		return
+	}
+	sup, total, err := s.txSvc.VerifySupply()
+	if err != nil && !errors.Is(err, store.ErrSupplyMismatch) {
+		writeError(c, err, nil)
+		return
+	}
+	resp := gin.H{
+		"total_supply":   sup.TotalSupply,
+		"genesis":        sup.Genesis,
+		"minted":         sup.Minted,
+		"burned":         sup.Burned,
+		"accounts_total": total,
+		"consistent":     err == nil,
+	}
+	if err != nil {
+		resp["error"] = err.Error()
+	}
+	c.JSON(http.StatusOK, resp)
+}
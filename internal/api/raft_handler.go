@@ -1,14 +1,23 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
 
+	"distributed_ledger_go/pkg/types"
+
 	"github.com/gin-gonic/gin"
 )
 
 type raftJoinRequest struct {
 	NodeID      string `json:"node_id"`
 	RaftAddress string `json:"raft_address"`
+	// HTTPAddress 为新节点对外的 HTTP 地址，登记后其它节点可把请求转发给它
+	HTTPAddress string `json:"http_address,omitempty"`
+	// GenesisHash 为新节点配置的创世哈希，非空时必须与集群一致
+	GenesisHash string `json:"genesis_hash,omitempty"`
+	// Identity 为新节点的身份地址，登记后其签发的检查点才会被接受
+	Identity string `json:"identity,omitempty"`
 }
 
 type raftRemoveRequest struct {
@@ -17,24 +26,24 @@ type raftRemoveRequest struct {
 
 func (s *Server) handleRaftJoin(c *gin.Context) {
 	if s.joinFunc == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "join unavailable"})
+		writeError(c, fmt.Errorf("join %w", ErrUnavailable), nil)
 		return
 	}
 	var req raftJoinRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, invalidRequest("%v", err), nil)
 		return
 	}
 	if req.NodeID == "" || req.RaftAddress == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "node_id and raft_address required"})
+		writeError(c, invalidRequest("node_id and raft_address required"), nil)
 		return
 	}
-	leader, err := s.joinFunc(req.NodeID, req.RaftAddress)
+	leader, err := s.joinFunc(req.NodeID, req.RaftAddress, req.HTTPAddress, req.GenesisHash, req.Identity)
 	if err != nil {
 		if leader != "" {
 			c.Header("X-Raft-Leader", leader)
 		}
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		writeError(c, err, nil)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
@@ -42,16 +51,16 @@ func (s *Server) handleRaftJoin(c *gin.Context) {
 
 func (s *Server) handleRaftRemove(c *gin.Context) {
 	if s.removeFunc == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "remove unavailable"})
+		writeError(c, fmt.Errorf("remove %w", ErrUnavailable), nil)
 		return
 	}
 	var req raftRemoveRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, invalidRequest("%v", err), nil)
 		return
 	}
 	if req.NodeID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "node_id required"})
+		writeError(c, invalidRequest("node_id required"), nil)
 		return
 	}
 	leader, err := s.removeFunc(req.NodeID)
@@ -59,7 +68,25 @@ func (s *Server) handleRaftRemove(c *gin.Context) {
 		if leader != "" {
 			c.Header("X-Raft-Leader", leader)
 		}
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		writeError(c, err, nil)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// handleRaftReport 在 leader 上接收 follower 对最新检查点的签名回报，用于按多数派检测 leader 自身的审计链分叉。
+func (s *Server) handleRaftReport(c *gin.Context) {
+	if s.reportFunc == nil {
+		writeError(c, fmt.Errorf("report %w", ErrUnavailable), nil)
+		return
+	}
+	var r types.CheckpointReport
+	if err := c.ShouldBindJSON(&r); err != nil {
+		writeError(c, invalidRequest("%v", err), nil)
+		return
+	}
+	if err := s.reportFunc(&r); err != nil {
+		writeError(c, err, nil)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
@@ -67,7 +94,7 @@ func (s *Server) handleRaftRemove(c *gin.Context) {
 
 func (s *Server) handleRaftStatus(c *gin.Context) {
 	if s.statusFunc == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "status unavailable"})
+		writeError(c, fmt.Errorf("status %w", ErrUnavailable), nil)
 		return
 	}
 	c.JSON(http.StatusOK, s.statusFunc())
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 读一致性级别，通过查询参数 consistency 指定，缺省为 stale。
+const (
+	// ReadStale 直接读取本地状态，follower 上可能落后于 leader
+	ReadStale = "stale"
+	// ReadLeader 只在确认自己仍是 leader 的节点上读取
+	ReadLeader = "leader"
+	// ReadLinearizable 在 leader 上等待此前提交的日志全部应用后再读取
+	ReadLinearizable = "linearizable"
+)
+
+// checkReadConsistency 按请求的一致性级别确认本节点可以提供读取，失败时直接写回错误响应。
+// 本节点不是 leader 时把请求转发给 leader，leader 未知时返回 503。
+func (s *Server) checkReadConsistency(c *gin.Context) bool {
+	level := c.DefaultQuery("consistency", ReadStale)
+	switch level {
+	case ReadStale:
+		return true
+	case ReadLeader, ReadLinearizable:
+	default:
+		writeError(c, invalidRequest("consistency must be linearizable, leader or stale"), nil)
+		return false
+	}
+	if s.leaderFunc != nil {
+		if leader, raftLeader, isLeader := s.leaderFunc(); !isLeader {
+			s.proxyToLeader(c, leader, raftLeader)
+			return false
+		}
+	}
+	if s.readFunc == nil {
+		writeError(c, fmt.Errorf("consistent read %w", ErrUnavailable), nil)
+		return false
+	}
+	leader, err := s.readFunc(level)
+	if err != nil {
+		if leader != "" {
+			c.Header("X-Raft-Leader", leader)
+		}
+		writeError(c, err, nil)
+		return false
+	}
+	return true
+}
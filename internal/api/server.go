@@ -1,10 +1,9 @@
 package api
 
 import (
-	"sync"
-
 	"distributed_ledger_go/internal/service"
-	"distributed_ledger_go/internal/types"
+	"distributed_ledger_go/internal/txVerify"
+	"distributed_ledger_go/pkg/types"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,24 +13,70 @@ type Server struct {
 	engine     *gin.Engine
 	accountSvc *service.AccountService
 	auditSvc   *service.AuditService
-	txSubmit   func(*types.Transaction) error
-	joinFunc   func(string, string) (string, error)
+	verifier   *service.AuditVerifier
+	txSvc      *service.TransactionService
+	validator  *txVerify.Validator
+	txSubmit   func(*types.Transaction) (*types.Receipt, error)
+	registerFn func(string) (string, error)
+	setRoleFn  func(string, string, string, uint64) error
+	joinFunc   func(string, string, string, string, string) (string, error)
 	removeFunc func(string) (string, error)
+	reportFunc func(*types.CheckpointReport) error
 	statusFunc func() map[string]interface{}
-	mu         sync.Mutex
-	hasCreator bool
+	signFunc   func([]byte) ([]byte, string, error)
+	readFunc   func(string) (string, error)
+	// leaderFunc 返回 leader 的 HTTP 地址、Raft 地址以及本节点是否为 leader
+	leaderFunc func() (string, string, bool)
+	// replay 记录签名请求已使用的 nonce
+	replay *replayCache
+}
+
+// Deps 为 Server 依赖的服务与节点回调。
+type Deps struct {
+	Account   *service.AccountService
+	Tx        *service.TransactionService
+	Audit     *service.AuditService
+	Verifier  *service.AuditVerifier
+	Validator *txVerify.Validator
+	// Submit 经 Raft 提交已签名交易并返回回执
+	Submit func(*types.Transaction) (*types.Receipt, error)
+	// Register 经 Raft 注册账户，返回其角色
+	Register func(string) (string, error)
+	// SetRole 经 Raft 变更角色：地址、角色、签名者、创世者 nonce
+	SetRole func(string, string, string, uint64) error
+	// Join 处理节点加入：节点 ID、Raft 地址、HTTP 地址、创世哈希、节点身份
+	Join   func(string, string, string, string, string) (string, error)
+	Remove func(string) (string, error)
+	Report func(*types.CheckpointReport) error
+	Status func() map[string]interface{}
+	// Sign 以节点身份私钥签名，返回签名与身份地址
+	Sign func([]byte) ([]byte, string, error)
+	// Read 按一致性级别完成读屏障，失败时返回 leader 地址
+	Read func(string) (string, error)
+	// Leader 返回 leader 的 HTTP 地址、Raft 地址以及本节点是否为 leader
+	Leader func() (string, string, bool)
 }
 
-func NewServer(account *service.AccountService, txSubmit func(*types.Transaction) error, audit *service.AuditService, joinFunc func(string, string) (string, error), removeFunc func(string) (string, error), statusFunc func() map[string]interface{}) *Server {
+func NewServer(d Deps) *Server {
 	engine := gin.Default()
 	s := &Server{
 		engine:     engine,
-		accountSvc: account,
-		auditSvc:   audit,
-		txSubmit:   txSubmit,
-		joinFunc:   joinFunc,
-		removeFunc: removeFunc,
-		statusFunc: statusFunc,
+		accountSvc: d.Account,
+		txSvc:      d.Tx,
+		validator:  d.Validator,
+		auditSvc:   d.Audit,
+		verifier:   d.Verifier,
+		txSubmit:   d.Submit,
+		registerFn: d.Register,
+		setRoleFn:  d.SetRole,
+		joinFunc:   d.Join,
+		removeFunc: d.Remove,
+		reportFunc: d.Report,
+		statusFunc: d.Status,
+		signFunc:   d.Sign,
+		readFunc:   d.Read,
+		leaderFunc: d.Leader,
+		replay:     newReplayCache(),
 	}
 	s.registerRoutes()
 	return s
@@ -42,21 +87,41 @@ func (s *Server) registerRoutes() {
 	s.engine.GET("/", func(c *gin.Context) {
 		c.File("./web/index.html")
 	})
-	s.engine.POST("/accounts/register", s.handleRegisterAccount)
+	// 写接口在 follower 上会被透明转发给 leader；本节点审计链分叉时业务写入直接拒绝
+	s.engine.POST("/accounts/register", s.rejectDiverged, s.forwardToLeader, s.handleRegisterAccount)
 	s.engine.GET("/accounts/:address", s.handleGetAccount)
-	s.engine.POST("/accounts/promote", s.handlePromoteAccount)
-	s.engine.POST("/accounts/demote", s.handleDemoteAccount)
+	s.engine.GET("/accounts/:address/transactions", s.handleAccountTransactions)
+	s.engine.POST("/accounts/promote", s.rejectDiverged, s.forwardToLeader, s.handlePromoteAccount)
+	s.engine.POST("/accounts/demote", s.rejectDiverged, s.forwardToLeader, s.handleDemoteAccount)
 
-	s.engine.POST("/transactions/mint", s.handleMint)
-	s.engine.POST("/transactions/transfer", s.handleTransfer)
-	s.engine.POST("/transactions/freeze", s.handleFreeze)
-	s.engine.POST("/transactions/unfreeze", s.handleUnfreeze)
+	s.engine.POST("/transactions/mint", s.rejectDiverged, s.forwardToLeader, s.handleMint)
+	s.engine.POST("/transactions/transfer", s.rejectDiverged, s.forwardToLeader, s.handleTransfer)
+	s.engine.POST("/transactions/freeze", s.rejectDiverged, s.forwardToLeader, s.handleFreeze)
+	s.engine.POST("/transactions/unfreeze", s.rejectDiverged, s.forwardToLeader, s.handleUnfreeze)
+	s.engine.POST("/transactions/burn", s.rejectDiverged, s.forwardToLeader, s.handleBurn)
+	s.engine.POST("/transactions/policy", s.rejectDiverged, s.forwardToLeader, s.handleSetPolicy)
+	s.engine.POST("/transactions/submit", s.rejectDiverged, s.forwardToLeader, s.handleSubmitTransaction)
 	s.engine.POST("/transactions/query", s.handleQueryTransactions)
+	s.engine.GET("/transactions/:hash", s.handleGetReceipt)
 
+	s.engine.GET("/ledger/supply", s.handleSupply)
+	s.engine.GET("/ledger/policy", s.handleGetPolicy)
+
+	s.engine.GET("/audit", s.handleAuditList)
+	s.engine.GET("/audit/head", s.handleAuditHead)
+	s.engine.GET("/audit/export", s.handleAuditExport)
+	s.engine.GET("/audit/consistency", s.handleAuditConsistency)
+	s.engine.GET("/audit/checkpoints", s.handleAuditCheckpoints)
 	s.engine.GET("/audit/:index", s.handleAuditEntry)
-	s.engine.POST("/raft/join", s.handleRaftJoin)
-	s.engine.POST("/raft/remove", s.handleRaftRemove)
+	s.engine.GET("/audit/:index/proof", s.handleAuditProof)
+	s.engine.POST("/raft/join", s.forwardToLeader, s.handleRaftJoin)
+	s.engine.POST("/raft/remove", s.forwardToLeader, s.handleRaftRemove)
+	s.engine.POST("/raft/report", s.forwardToLeader, s.handleRaftReport)
 	s.engine.GET("/raft/status", s.handleRaftStatus)
+
+	s.engine.GET("/admin/audit/verify", s.requireAdmin, s.handleAuditVerifyStatus)
+	s.engine.POST("/admin/audit/verify", s.requireAdmin, s.handleAuditVerifyStart)
+	s.engine.POST("/admin/audit/divergence/clear", s.requireAdmin, s.handleAuditDivergenceClear)
 }
 
 func (s *Server) ListenAndServe(addr string) error {
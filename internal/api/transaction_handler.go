@@ -1,12 +1,20 @@
 package api
 
 import (
+	"bytes"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
+	"distributed_ledger_go/internal/store"
 	"distributed_ledger_go/internal/txVerify"
-	"distributed_ledger_go/internal/types"
 	"distributed_ledger_go/pkg/crypto"
+	"distributed_ledger_go/pkg/txhash"
+	"distributed_ledger_go/pkg/types"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,9 +24,36 @@ type txRequest struct {
 	Receiver   string `json:"receiver"`
 	Amount     uint64 `json:"amount"`
 	Nonce      uint64 `json:"nonce"`
+	ValidUntil int64  `json:"valid_until"`
 	PrivateKey string `json:"private_key"`
 }
 
+// signedTxRequest 为客户端离线签名后提交的完整交易，签名为 hex 编码的 ASN.1 DER。
+type signedTxRequest struct {
+	ChainID    string       `json:"chain_id"`
+	Type       types.TxType `json:"type"`
+	Sender     string       `json:"sender"`
+	Receiver   string       `json:"receiver"`
+	Amount     uint64       `json:"amount"`
+	Nonce      uint64       `json:"nonce"`
+	ValidUntil int64        `json:"valid_until"`
+	// Data 为 hex 编码的附加数据，TxTypeSetPolicy 时为 JSON 编码的额度策略
+	Data      string `json:"data"`
+	Signature string `json:"signature"`
+}
+
+// policyRequest 为创始者设置额度策略的请求，Policy 原样作为交易的 Data 签名。
+type policyRequest struct {
+	Sender     string          `json:"sender"`
+	Nonce      uint64          `json:"nonce"`
+	ValidUntil int64           `json:"valid_until"`
+	PrivateKey string          `json:"private_key"`
+	Policy     json.RawMessage `json:"policy"`
+}
+
+// 查询签名允许的时间偏差，超出视为过期请求。
+const querySignatureWindow = 5 * time.Minute
+
 func (s *Server) handleMint(c *gin.Context) {
 	s.handleTransaction(c, types.TxTypeMint)
 }
@@ -35,135 +70,332 @@ func (s *Server) handleUnfreeze(c *gin.Context) {
 	s.handleTransaction(c, types.TxTypeUnfreeze)
 }
 
+func (s *Server) handleBurn(c *gin.Context) {
+	s.handleTransaction(c, types.TxTypeBurn)
+}
+
 func (s *Server) handleTransaction(c *gin.Context, txType types.TxType) {
 	var req txRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, invalidRequest("%v", err), nil)
 		return
 	}
+	if txType == types.TxTypeBurn && req.Receiver == "" {
+		// 销毁只作用于发送方自己的余额
+		req.Receiver = req.Sender
+	}
 	if req.Sender == "" || req.Receiver == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "sender & receiver required"})
+		writeError(c, invalidRequest("sender & receiver required"), nil)
 		return
 	}
 	if req.PrivateKey == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "private_key required"})
+		writeError(c, invalidRequest("private_key required"), nil)
 		return
 	}
-	if txType == types.TxTypeMint {
-		receiverAcc, err := s.accountSvc.GetAccount(req.Receiver)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-		if receiverAcc.Role != types.RoleAdmin {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "mint receiver must be ADMIN"})
-			return
-		}
+	if !s.checkMintReceiver(c, txType, req.Receiver) {
+		return
 	}
 	tx := types.Transaction{
-		Type:     txType,
-		Sender:   req.Sender,
-		Receiver: req.Receiver,
-		Amount:   req.Amount,
-		Nonce:    req.Nonce,
+		ChainID:    s.validator.ChainID(),
+		Type:       txType,
+		Sender:     req.Sender,
+		Receiver:   req.Receiver,
+		Amount:     req.Amount,
+		Nonce:      req.Nonce,
+		ValidUntil: req.ValidUntil,
 	}
 
 	priv, err := crypto.HexToPrivateKey(req.PrivateKey)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, invalidRequest("%v", err), nil)
 		return
 	}
-	hash := txVerify.TxHash(tx)
+	hash := txhash.TxHash(tx)
 	sig, err := crypto.Sign(priv, hash)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err, nil)
 		return
 	}
 	tx.Signature = sig
 
-	if err := s.txSubmit(&tx); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	s.submitTransaction(c, &tx)
+}
+
+// handleSetPolicy 由节点代为签名提交额度策略交易，新策略整体替换旧策略。
+func (s *Server) handleSetPolicy(c *gin.Context) {
+	var req policyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, invalidRequest("%v", err), nil)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"status": "ok"})
-}
+	if req.Sender == "" || req.PrivateKey == "" {
+		writeError(c, invalidRequest("sender & private_key required"), nil)
+		return
+	}
+	data := new(bytes.Buffer)
+	if err := json.Compact(data, req.Policy); err != nil {
+		writeError(c, fmt.Errorf("%w: policy required", store.ErrInvalidPolicy), nil)
+		return
+	}
+	if _, err := store.ParsePolicy(data.Bytes()); err != nil {
+		writeError(c, err, nil)
+		return
+	}
+	tx := types.Transaction{
+		ChainID:    s.validator.ChainID(),
+		Type:       types.TxTypeSetPolicy,
+		Sender:     req.Sender,
+		Receiver:   req.Sender,
+		Nonce:      req.Nonce,
+		ValidUntil: req.ValidUntil,
+		Data:       data.Bytes(),
+	}
 
-type queryRequest struct {
-	RequesterAddress string `json:"requester_address"`
-	PrivateKey       string `json:"private_key"`
-}
+	priv, err := crypto.HexToPrivateKey(req.PrivateKey)
+	if err != nil {
+		writeError(c, invalidRequest("%v", err), nil)
+		return
+	}
+	sig, err := crypto.Sign(priv, txhash.TxHash(tx))
+	if err != nil {
+		writeError(c, err, nil)
+		return
+	}
+	tx.Signature = sig
 
-type transactionRecord struct {
-	Index    uint64       `json:"index"`
-	Type     types.TxType `json:"type"`
-	Sender   string       `json:"sender"`
-	Receiver string       `json:"receiver"`
-	Amount   uint64       `json:"amount"`
-	Nonce    uint64       `json:"nonce"`
+	s.submitTransaction(c, &tx)
 }
 
-func (s *Server) handleQueryTransactions(c *gin.Context) {
-	var req queryRequest
+// handleSubmitTransaction 接收客户端已签名的交易，节点只校验签名而不接触私钥。
+func (s *Server) handleSubmitTransaction(c *gin.Context) {
+	var req signedTxRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, invalidRequest("%v", err), nil)
 		return
 	}
-	if req.RequesterAddress == "" || req.PrivateKey == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "requester_address and private_key required"})
+	if req.Sender == "" || req.Receiver == "" {
+		writeError(c, invalidRequest("sender & receiver required"), nil)
 		return
 	}
-	priv, err := crypto.HexToPrivateKey(req.PrivateKey)
+	if req.Signature == "" {
+		writeError(c, invalidRequest("signature required"), nil)
+		return
+	}
+	sig, err := hex.DecodeString(req.Signature)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, invalidRequest("invalid signature: not hex"), nil)
 		return
 	}
-	addrHex, err := crypto.PublicKeyToHex(&priv.PublicKey)
+	data, err := hex.DecodeString(req.Data)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, invalidRequest("invalid data: not hex"), nil)
 		return
 	}
-	if addrHex != req.RequesterAddress {
-		c.JSON(http.StatusForbidden, gin.H{"error": "private key mismatch"})
+	tx := types.Transaction{
+		ChainID:    req.ChainID,
+		Type:       req.Type,
+		Sender:     req.Sender,
+		Receiver:   req.Receiver,
+		Amount:     req.Amount,
+		Nonce:      req.Nonce,
+		ValidUntil: req.ValidUntil,
+		Signature:  sig,
+	}
+	if len(data) > 0 {
+		tx.Data = data
+	}
+	if tx.ChainID != s.validator.ChainID() {
+		writeError(c, fmt.Errorf("%w: transaction signed for another chain", txVerify.ErrChainMismatch), nil)
+		return
+	}
+	if err := s.validator.VerifySignature(tx); err != nil {
+		writeError(c, fmt.Errorf("%w: %v", txVerify.ErrInvalidSignature, err), nil)
+		return
+	}
+	if !s.checkMintReceiver(c, tx.Type, tx.Receiver) {
+		return
+	}
+
+	s.submitTransaction(c, &tx)
+}
+
+// submitTransaction 提交交易到 Raft 并返回回执；失败时回执中包含错误原因。
+func (s *Server) submitTransaction(c *gin.Context, tx *types.Transaction) {
+	receipt, err := s.txSubmit(tx)
+	if err != nil {
+		var extra gin.H
+		if receipt != nil {
+			extra = gin.H{"receipt": receipt}
+		}
+		writeError(c, err, extra)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "receipt": receipt})
+}
+
+// handleGetReceipt 按交易哈希查询回执，包括失败交易。
+func (s *Server) handleGetReceipt(c *gin.Context) {
+	hash := strings.ToLower(c.Param("hash"))
+	if _, err := hex.DecodeString(hash); err != nil || len(hash) != 64 {
+		writeError(c, invalidRequest("invalid transaction hash"), nil)
+		return
+	}
+	receipt, err := s.txSvc.GetReceipt(hash)
+	if err != nil {
+		writeError(c, err, nil)
+		return
+	}
+	c.JSON(http.StatusOK, receipt)
+}
+
+// checkMintReceiver 确保铸币只能发给管理员，不满足时直接写回错误响应。
+func (s *Server) checkMintReceiver(c *gin.Context, txType types.TxType, receiver string) bool {
+	if txType != types.TxTypeMint {
+		return true
+	}
+	receiverAcc, err := s.accountSvc.GetAccount(receiver)
+	if err != nil {
+		writeError(c, err, nil)
+		return false
+	}
+	if receiverAcc.Role != types.RoleAdmin {
+		writeError(c, fmt.Errorf("%w: mint receiver must be ADMIN", txVerify.ErrInvalidReceiver), nil)
+		return false
+	}
+	return true
+}
+
+// queryRequest 支持两种鉴权方式：提交私钥，或提交对 RequestHash 的签名、Unix 秒级时间戳与一次性 nonce。
+type queryRequest struct {
+	RequesterAddress string `json:"requester_address"`
+	PrivateKey       string `json:"private_key"`
+	Timestamp        int64  `json:"timestamp"`
+	Nonce            string `json:"nonce"`
+	Signature        string `json:"signature"`
+	// Cursor 为上一页返回的 next_cursor，为 0 时从头查询
+	Cursor uint64 `json:"cursor"`
+}
+
+func (s *Server) handleQueryTransactions(c *gin.Context) {
+	var req queryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, invalidRequest("%v", err), nil)
+		return
+	}
+	if req.RequesterAddress == "" || (req.PrivateKey == "" && req.Signature == "") {
+		writeError(c, invalidRequest("requester_address and private_key or signature required"), nil)
+		return
+	}
+	if !s.authenticateRequester(c, req) {
 		return
 	}
 	acc, err := s.accountSvc.GetAccount(req.RequesterAddress)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, err, nil)
 		return
 	}
-	entries, err := s.auditSvc.ListEntries()
+	// 所有角色都走流水索引：普通用户查个人流水，管理员查全网转账，创世者查发给管理员的铸币
+	filter := store.HistoryFilter{Cursor: req.Cursor, Limit: maxHistoryLimit}
+	var records []*types.HistoryRecord
+	var next uint64
+	switch acc.Role {
+	case types.RoleAdmin:
+		records, next, err = s.txSvc.ListHistoryByType(types.TxTypeTransfer, filter)
+	case types.RoleCreator:
+		filter.Match = s.mintToAdmin()
+		records, next, err = s.txSvc.ListHistoryByType(types.TxTypeMint, filter)
+	default:
+		// 分页与过滤请使用 GET /accounts/:address/transactions
+		records, next, err = s.txSvc.ListHistory(acc.Address, filter)
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err, nil)
 		return
 	}
-	var result []transactionRecord
-	totalMint := uint64(0)
-	for _, e := range entries {
-		var tx types.Transaction
-		if err := json.Unmarshal(e.TxBytes, &tx); err != nil {
-			continue
+	if records == nil {
+		records = []*types.HistoryRecord{}
+	}
+	resp := gin.H{"transactions": records, "next_cursor": next}
+	if acc.Role == types.RoleCreator {
+		// 累计铸币与发行量取自复制的计数器，不再扫描审计链累加
+		sup, err := s.txSvc.Supply()
+		if err != nil {
+			writeError(c, err, nil)
+			return
 		}
-		switch acc.Role {
-		case types.RoleAdmin:
-			if tx.Type == types.TxTypeTransfer {
-				result = append(result, transactionRecord{e.Index, tx.Type, tx.Sender, tx.Receiver, tx.Amount, tx.Nonce})
-			}
-		case types.RoleCreator:
-			if tx.Type == types.TxTypeMint {
-				receiverAcc, err := s.accountSvc.GetAccount(tx.Receiver)
-				if err == nil && receiverAcc.Role == types.RoleAdmin {
-					result = append(result, transactionRecord{e.Index, tx.Type, tx.Sender, tx.Receiver, tx.Amount, tx.Nonce})
-					totalMint += tx.Amount
-				}
+		resp["total_minted"] = sup.Minted
+		resp["total_supply"] = sup.TotalSupply
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// mintToAdmin 返回流水过滤条件：只保留接收者当前仍为管理员的铸币记录。
+func (s *Server) mintToAdmin() func(*types.HistoryRecord) (bool, error) {
+	admins := map[string]bool{}
+	return func(rec *types.HistoryRecord) (bool, error) {
+		isAdmin, ok := admins[rec.Receiver]
+		if !ok {
+			acc, err := s.accountSvc.GetAccount(rec.Receiver)
+			if err != nil && !errors.Is(err, store.ErrAccountNotFound) {
+				return false, err
 			}
-		default:
-			c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permission"})
-			return
+			isAdmin = err == nil && acc.Role == types.RoleAdmin
+			admins[rec.Receiver] = isAdmin
 		}
+		return isAdmin, nil
 	}
-	resp := gin.H{"transactions": result}
-	if acc.Role == types.RoleCreator {
-		resp["total_minted"] = totalMint
+}
+
+// checkSignatureWindow 确认签名时间戳在节点时间前后 querySignatureWindow 内，否则直接写回错误响应。
+func checkSignatureWindow(c *gin.Context, timestamp int64) bool {
+	skew := time.Since(time.Unix(timestamp, 0))
+	if skew > querySignatureWindow || skew < -querySignatureWindow {
+		writeError(c, fmt.Errorf("%w: query signature expired", ErrUnauthenticated), nil)
+		return false
 	}
-	c.JSON(http.StatusOK, resp)
+	return true
+}
+
+// authenticateRequester 校验请求者身份，失败时直接写回错误响应。签名载荷为 txhash.RequestHash，
+// 绑定 HTTP 方法、含查询参数的路径与 nonce，同一 nonce 在有效期内只能使用一次，截获的请求无法被重放或改写。
+func (s *Server) authenticateRequester(c *gin.Context, req queryRequest) bool {
+	if req.Signature != "" {
+		sig, err := hex.DecodeString(req.Signature)
+		if err != nil {
+			writeError(c, invalidRequest("invalid signature: not hex"), nil)
+			return false
+		}
+		if req.Nonce == "" || len(req.Nonce) > maxRequestNonceLen {
+			writeError(c, invalidRequest("invalid nonce"), nil)
+			return false
+		}
+		if !checkSignatureWindow(c, req.Timestamp) {
+			return false
+		}
+		if err := s.validator.VerifyRequestSignature(req.RequesterAddress, c.Request.Method, c.Request.URL.RequestURI(), req.Timestamp, req.Nonce, sig); err != nil {
+			writeError(c, fmt.Errorf("%w: %v", txVerify.ErrInvalidSignature, err), nil)
+			return false
+		}
+		// 以 (请求者, nonce) 而非签名字节去重：ECDSA 签名可延展，改写后的签名仍能通过校验
+		if !s.replay.consume(req.RequesterAddress+"/"+req.Nonce, req.Timestamp) {
+			writeError(c, fmt.Errorf("%w: request nonce already used", txVerify.ErrInvalidSignature), nil)
+			return false
+		}
+		return true
+	}
+	priv, err := crypto.HexToPrivateKey(req.PrivateKey)
+	if err != nil {
+		writeError(c, invalidRequest("%v", err), nil)
+		return false
+	}
+	addrHex, err := crypto.PublicKeyToHex(&priv.PublicKey)
+	if err != nil {
+		writeError(c, err, nil)
+		return false
+	}
+	if addrHex != req.RequesterAddress {
+		writeError(c, fmt.Errorf("%w: private key mismatch", txVerify.ErrPermissionDenied), nil)
+		return false
+	}
+	return true
 }
@@ -0,0 +1,79 @@
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"distributed_ledger_go/pkg/crypto"
+	"distributed_ledger_go/pkg/txhash"
+	"distributed_ledger_go/pkg/types"
+)
+
+// loadGenesis 读取并校验 genesis.json，返回创世配置及其哈希。
+// 文件中的 genesis_hash 非空时必须与按内容计算的哈希一致。
+func loadGenesis(path, chainID string) (*types.Genesis, string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	var g types.Genesis
+	if err := json.Unmarshal(b, &g); err != nil {
+		return nil, "", fmt.Errorf("parse genesis %s: %w", path, err)
+	}
+	if g.ChainID != chainID {
+		return nil, "", fmt.Errorf("genesis chain id %q does not match configured chain_id %q", g.ChainID, chainID)
+	}
+	if _, err := crypto.HexToPublicKey(g.Creator); err != nil {
+		return nil, "", fmt.Errorf("genesis creator: %w", err)
+	}
+	seen := map[string]bool{g.Creator: true}
+	for _, admin := range g.Admins {
+		if seen[admin] {
+			return nil, "", fmt.Errorf("genesis admin %s duplicated or is the creator", admin)
+		}
+		seen[admin] = true
+		if _, err := crypto.HexToPublicKey(admin); err != nil {
+			return nil, "", fmt.Errorf("genesis admin %s: %w", admin, err)
+		}
+	}
+	var supply uint64
+	for address, amount := range g.Balances {
+		if _, err := crypto.HexToPublicKey(address); err != nil {
+			return nil, "", fmt.Errorf("genesis balance %s: %w", address, err)
+		}
+		if supply+amount < supply {
+			return nil, "", errors.New("genesis balances overflow total supply")
+		}
+		supply += amount
+	}
+	hash := txhash.GenesisHash(&g)
+	if g.GenesisHash != "" && g.GenesisHash != hash {
+		return nil, "", fmt.Errorf("genesis_hash %s does not match content hash %s", g.GenesisHash, hash)
+	}
+	return &g, hash, nil
+}
+
+// resolveGenesis 合并 genesis 文件与 creator 配置：只配置 creator 时生成只含创世者的创世配置，
+// 二者同时配置时创世者必须一致；均未配置时返回 nil。
+func resolveGenesis(path, creator, chainID string) (*types.Genesis, string, error) {
+	if path != "" {
+		g, hash, err := loadGenesis(path, chainID)
+		if err != nil {
+			return nil, "", err
+		}
+		if creator != "" && creator != g.Creator {
+			return nil, "", fmt.Errorf("configured creator %s does not match genesis creator %s", creator, g.Creator)
+		}
+		return g, hash, nil
+	}
+	if creator == "" {
+		return nil, "", nil
+	}
+	if _, err := crypto.HexToPublicKey(creator); err != nil {
+		return nil, "", fmt.Errorf("creator: %w", err)
+	}
+	g := &types.Genesis{ChainID: chainID, Creator: creator}
+	return g, txhash.GenesisHash(g), nil
+}
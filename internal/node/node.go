@@ -2,6 +2,8 @@ package node
 
 import (
 	"bytes"
+	"crypto/ecdsa"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -17,14 +19,27 @@ import (
 	"distributed_ledger_go/internal/service"
 	"distributed_ledger_go/internal/store"
 	"distributed_ledger_go/internal/txVerify"
-	"distributed_ledger_go/internal/types"
+	"distributed_ledger_go/pkg/audit"
+	"distributed_ledger_go/pkg/crypto"
+	"distributed_ledger_go/pkg/types"
 
 	"github.com/dgraph-io/badger/v3"
 	"github.com/hashicorp/raft"
 	raftboltdb "github.com/hashicorp/raft-boltdb"
 )
 
-const commandTransaction = "transaction"
+const (
+	commandTransaction     = "transaction"
+	commandRegisterAccount = "register_account"
+	commandSetRole         = "set_role"
+	commandCheckpoint      = "checkpoint"
+	commandSetPeer         = "set_peer"
+	commandGenesis         = "genesis"
+	commandSetIdentity     = "set_identity"
+)
+
+// 引导节点等待自己当选 leader 以写入创世状态的最长时间
+const genesisTimeout = 10 * time.Second
 
 // Node 表示一个账本节点，封装业务服务与 Raft 复制。
 type Node struct {
@@ -34,27 +49,69 @@ type Node struct {
 	accountSvc *service.AccountService
 	txSvc      *service.TransactionService
 	auditSvc   *service.AuditService
+	verifier   *service.AuditVerifier
+	store      *store.Store
 
 	raftNode *raft.Raft
 	hasState bool
+
+	identity     *ecdsa.PrivateKey
+	identityAddr string
+
+	// genesis 为配置的创世状态，genesisHash 为其哈希；未配置 genesis 时均为空
+	genesis     *types.Genesis
+	genesisHash string
+
+	stopCh chan struct{}
 }
 
 // joinRequest 表示节点加入集群时提交的信息。
 type joinRequest struct {
 	NodeID      string `json:"node_id"`
 	RaftAddress string `json:"raft_address"`
+	HTTPAddress string `json:"http_address,omitempty"`
+	GenesisHash string `json:"genesis_hash,omitempty"`
+	// Identity 为新节点的身份地址，leader 接纳后登记到节点身份表
+	Identity string `json:"identity,omitempty"`
 }
 
 // raftCommand 为 Raft 日志条目的统一格式。
 type raftCommand struct {
 	Type        string             `json:"type"`
 	Transaction *types.Transaction `json:"transaction,omitempty"`
+	Address     string             `json:"address,omitempty"`
+	Role        string             `json:"role,omitempty"`
+	Checkpoint  *types.Checkpoint  `json:"checkpoint,omitempty"`
+	// RaftAddress 与 HTTPAddress 用于 set_peer，HTTPAddress 为空表示删除该节点
+	RaftAddress string         `json:"raft_address,omitempty"`
+	HTTPAddress string         `json:"http_address,omitempty"`
+	Genesis     *types.Genesis `json:"genesis,omitempty"`
+	// Signer 与 Nonce 用于创世者签名的 set_role，状态机据此校验并递增创世者 nonce；旧日志中为空
+	Signer string `json:"signer,omitempty"`
+	Nonce  uint64 `json:"nonce,omitempty"`
+	// ProposedAt 为 leader 提议时的时间（Unix 秒），供各副本确定性地判断交易是否过期
+	ProposedAt int64 `json:"proposed_at,omitempty"`
+	// PayloadVersion 为审计载荷编码版本，升级前的日志没有该字段（0，即 JSON），重放时保持原编码
+	PayloadVersion byte `json:"payload_version,omitempty"`
+	// NodeID 与 Identity 用于 set_identity，Identity 为空表示删除该节点的身份
+	NodeID   string `json:"node_id,omitempty"`
+	Identity string `json:"identity,omitempty"`
+}
+
+// applyResponse 为 FSM 执行交易或注册命令后的返回值，失败时同时携带失败回执与错误。
+type applyResponse struct {
+	Receipt *types.Receipt
+	// Role 为注册命令最终确定的账户角色
+	Role string
+	Err  error
 }
 
 // fsm 实现 raft.FSM 接口，负责真正的状态变更。
 type fsm struct {
-	txSvc *service.TransactionService
-	db    *badger.DB
+	txSvc      *service.TransactionService
+	accountSvc *service.AccountService
+	auditSvc   *service.AuditService
+	store      *store.Store
 }
 
 // NewNode 根据配置初始化业务服务与 Raft 实例。
@@ -68,12 +125,58 @@ func NewNode(cfg *config.Config) (*Node, error) {
 	auditStore := store.NewStore(db)
 	accountSvc := service.NewAccountService(storeDB)
 	auditSvc := service.NewAuditService(auditStore)
-	if err := auditSvc.VerifyChain(); err != nil {
+	verifier := service.NewAuditVerifier(auditStore, cfg.AuditVerifyRate)
+	// 启动时只校验上次水位线之后的条目，全量校验在后台限速进行
+	tailCount, err := verifier.VerifyTail()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	log.Printf("audit chain tail verified: %d new entries", tailCount)
+	if err := auditSvc.CheckLatestCheckpoint(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := auditSvc.EnsureMerkle(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	genesis, genesisHash, err := resolveGenesis(cfg.Genesis, cfg.Creator, cfg.ChainID)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if genesis != nil {
+		stored, err := accountSvc.GenesisHash()
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		if stored != "" && stored != genesisHash {
+			db.Close()
+			return nil, fmt.Errorf("%w: local data %s, genesis file %s", store.ErrGenesisMismatch, stored, genesisHash)
+		}
+		log.Printf("genesis loaded: creator %s (hash %s)", genesis.Creator, genesisHash)
+	}
+	identity, identityAddr, err := crypto.LoadOrCreateKey(cfg.IdentityKey)
+	if err != nil {
 		db.Close()
 		return nil, err
 	}
-	validator := txVerify.NewValidator(storeDB)
+	validator := txVerify.NewValidator(storeDB, cfg.ChainID)
 	txSvc := service.NewTransactionService(storeDB, validator, auditSvc)
+	if err := txSvc.EnsureHistoryIndex(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := txSvc.EnsureSupply(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if _, _, err := txSvc.VerifySupply(); err != nil {
+		// 余额与发行量不一致说明本地状态已损坏，仅告警，由运维比对其它副本后处理
+		log.Printf("ALARM supply invariant violated: %v", err)
+	}
 
 	n := &Node{
 		cfg:        cfg,
@@ -81,6 +184,16 @@ func NewNode(cfg *config.Config) (*Node, error) {
 		accountSvc: accountSvc,
 		txSvc:      txSvc,
 		auditSvc:   auditSvc,
+		verifier:   verifier,
+		store:      storeDB,
+
+		identity:     identity,
+		identityAddr: identityAddr,
+
+		genesis:     genesis,
+		genesisHash: genesisHash,
+
+		stopCh: make(chan struct{}),
 	}
 
 	hasState, err := n.initRaft()
@@ -89,6 +202,19 @@ func NewNode(cfg *config.Config) (*Node, error) {
 		return nil, err
 	}
 	n.hasState = hasState
+	if !hasState && cfg.RaftBootstrap {
+		// 首次引导时在对外提供服务前写入创世状态，保证它是集群的第一条业务命令；
+		// 创世者只能来自配置，不能由注册产生
+		if genesis == nil {
+			n.Close()
+			return nil, fmt.Errorf("node %s: bootstrapping a new cluster (raft_bootstrap: true) requires a creator: "+
+				"set \"creator: <address>\" (generate one with \"ledgerctl keygen\") or \"genesis: <path to genesis.json>\" in the node config", cfg.NodeID)
+		}
+		if err := n.bootstrapGenesis(); err != nil {
+			n.Close()
+			return nil, err
+		}
+	}
 	if !hasState && !cfg.RaftBootstrap {
 		if len(cfg.RaftPeers) == 0 {
 			log.Printf("node %s 启动时未配置 raft_peers，等待管理员调用 /raft/join", cfg.NodeID)
@@ -100,7 +226,26 @@ func NewNode(cfg *config.Config) (*Node, error) {
 		}
 	}
 
-	n.server = api.NewServer(accountSvc, n.proposeTransaction, auditSvc, n.handleJoinRequest, n.handleLeaveRequest, n.raftStatus)
+	n.server = api.NewServer(api.Deps{
+		Account:   accountSvc,
+		Tx:        txSvc,
+		Audit:     auditSvc,
+		Verifier:  verifier,
+		Validator: validator,
+		Submit:    n.proposeTransaction,
+		Register:  n.proposeRegister,
+		SetRole:   n.proposeSetRole,
+		Join:      n.handleJoinRequest,
+		Remove:    n.handleLeaveRequest,
+		Report:    n.handleCheckpointReport,
+		Status:    n.raftStatus,
+		Sign:      n.sign,
+		Read:      n.ensureRead,
+		Leader:    n.leaderAddrs,
+	})
+	go n.checkpointLoop(n.stopCh)
+	go n.leaderLoop(n.stopCh)
+	verifier.StartFull()
 	return n, nil
 }
 
@@ -113,7 +258,7 @@ func (n *Node) initRaft() (bool, error) {
 	rConfig := raft.DefaultConfig()
 	rConfig.LocalID = raft.ServerID(n.cfg.NodeID)
 
-	fsm := &fsm{txSvc: n.txSvc, db: n.db}
+	fsm := &fsm{txSvc: n.txSvc, accountSvc: n.accountSvc, auditSvc: n.auditSvc, store: n.store}
 	// 账本
 	logStore, err := raftboltdb.NewBoltStore(filepath.Join(n.cfg.RaftDir, "raft-log.bolt"))
 	if err != nil {
@@ -172,6 +317,13 @@ func (n *Node) Start() error {
 
 // Close 关闭 Raft 和 Badger。
 func (n *Node) Close() error {
+	if n.stopCh != nil {
+		close(n.stopCh)
+		n.stopCh = nil
+	}
+	if n.verifier != nil {
+		n.verifier.Close()
+	}
 	if n.raftNode != nil {
 		future := n.raftNode.Shutdown()
 		_ = future.Error()
@@ -182,75 +334,466 @@ func (n *Node) Close() error {
 	return nil
 }
 
-// proposeTransaction 将交易序列化后提交给 Raft 日志。
-func (n *Node) proposeTransaction(tx *types.Transaction) error {
+// proposeTransaction 将交易序列化后提交给 Raft 日志，返回交易回执。
+func (n *Node) proposeTransaction(tx *types.Transaction) (*types.Receipt, error) {
 	if n.raftNode == nil {
-		return errors.New("raft not initialized")
+		return nil, errors.New("raft not initialized")
+	}
+	resp, err := n.propose(raftCommand{
+		Type:           commandTransaction,
+		Transaction:    tx,
+		ProposedAt:     time.Now().Unix(),
+		PayloadVersion: audit.CurrentPayloadVersion,
+	})
+	if resp == nil {
+		return nil, err
+	}
+	return resp.Receipt, err
+}
+
+// proposeRegister 通过 Raft 复制账户注册。新注册的账户总是普通用户，创世者只能由创世配置确定。
+func (n *Node) proposeRegister(address string) (string, error) {
+	resp, err := n.propose(raftCommand{Type: commandRegisterAccount, Address: address, Role: types.RoleUser})
+	if err != nil || resp == nil {
+		return "", err
+	}
+	return resp.Role, nil
+}
+
+// proposeSetRole 通过 Raft 复制账户角色变更；signer 非空时为创世者签名的请求，nonce 为其当前 nonce + 1。
+func (n *Node) proposeSetRole(address, role, signer string, nonce uint64) error {
+	_, err := n.propose(raftCommand{Type: commandSetRole, Address: address, Role: role, Signer: signer, Nonce: nonce})
+	return err
+}
+
+// leaderLoop 在本节点当选 leader 时写入尚未复制的创世状态，登记自己的 HTTP 地址供 follower 转发写请求，
+// 并登记自己的身份地址，之后签发的检查点才会被各副本接受。
+func (n *Node) leaderLoop(stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case isLeader := <-n.raftNode.LeaderCh():
+			if !isLeader {
+				continue
+			}
+			if err := n.proposeGenesis(); err != nil {
+				log.Printf("propose genesis failed: %v", err)
+			}
+			if err := n.advertise(); err != nil {
+				log.Printf("advertise http address failed: %v", err)
+			}
+			if err := n.registerIdentity(); err != nil {
+				log.Printf("register node identity failed: %v", err)
+			}
+		}
+	}
+}
+
+// bootstrapGenesis 等待引导节点当选 leader 后同步复制创世状态，超时视为启动失败。
+func (n *Node) bootstrapGenesis() error {
+	deadline := time.Now().Add(genesisTimeout)
+	for n.raftNode.State() != raft.Leader {
+		if time.Now().After(deadline) {
+			return errors.New("timed out waiting for leadership to apply genesis")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if err := n.proposeGenesis(); err != nil {
+		return fmt.Errorf("apply genesis: %w", err)
+	}
+	log.Printf("genesis %s applied at bootstrap", n.genesisHash)
+	return nil
+}
+
+// proposeGenesis 在集群尚未写入创世状态时，通过 Raft 复制配置的创世状态。
+func (n *Node) proposeGenesis() error {
+	if n.genesis == nil {
+		return nil
+	}
+	stored, err := n.accountSvc.GenesisHash()
+	if err != nil || stored != "" {
+		return err
 	}
-	payload, err := json.Marshal(raftCommand{Type: commandTransaction, Transaction: tx})
+	creator, err := n.accountSvc.Creator()
 	if err != nil {
 		return err
 	}
-	future := n.raftNode.Apply(payload, 5*time.Second)
-	if err := future.Error(); err != nil {
+	if creator != "" {
+		return fmt.Errorf("%w: ledger was initialized without genesis (creator %s)", store.ErrGenesisMismatch, creator)
+	}
+	_, err = n.propose(raftCommand{Type: commandGenesis, Genesis: n.genesis})
+	return err
+}
+
+// advertise 登记本节点的 HTTP 地址，已登记且未变化时跳过。
+func (n *Node) advertise() error {
+	raftAddr := n.cfg.RaftBind
+	current, err := n.store.GetPeer(raftAddr)
+	if err != nil || current == n.cfg.HTTPAdvertise {
+		return err
+	}
+	return n.proposeSetPeer(raftAddr, n.cfg.HTTPAdvertise)
+}
+
+// registerIdentity 登记本节点的身份地址，已登记且未变化时跳过。
+func (n *Node) registerIdentity() error {
+	identities, err := n.auditSvc.Identities()
+	if err != nil || identities[n.cfg.NodeID] == n.identityAddr {
+		return err
+	}
+	return n.proposeSetIdentity(n.cfg.NodeID, n.identityAddr)
+}
+
+// proposeSetIdentity 通过 Raft 复制节点 ID 到身份地址的映射，identity 为空表示删除。
+func (n *Node) proposeSetIdentity(nodeID, identity string) error {
+	_, err := n.propose(raftCommand{Type: commandSetIdentity, NodeID: nodeID, Identity: identity})
+	return err
+}
+
+// proposeSetPeer 通过 Raft 复制节点 Raft 地址到 HTTP 地址的映射，httpAddr 为空表示删除。
+func (n *Node) proposeSetPeer(raftAddr, httpAddr string) error {
+	_, err := n.propose(raftCommand{Type: commandSetPeer, RaftAddress: raftAddr, HTTPAddress: httpAddr})
+	return err
+}
+
+// leaderAddrs 返回 leader 的 HTTP 地址、Raft 地址以及本节点是否为 leader；
+// leader 未知时两者均为空，HTTP 地址未登记时只返回 Raft 地址。
+func (n *Node) leaderAddrs() (string, string, bool) {
+	if n.raftNode == nil {
+		return "", "", false
+	}
+	leader := string(n.raftNode.Leader())
+	if n.raftNode.State() == raft.Leader {
+		return n.cfg.HTTPAdvertise, leader, true
+	}
+	if leader == "" {
+		return "", "", false
+	}
+	httpAddr, err := n.store.GetPeer(leader)
+	if err != nil {
+		log.Printf("load peer %s failed: %v", leader, err)
+		return "", leader, false
+	}
+	return httpAddr, leader, false
+}
+
+// leaderHint 返回写入 X-Raft-Leader 头的 leader HTTP 地址；leader 尚未登记 HTTP 地址时返回空串，
+// 调用方据此重试时不会向 Raft 端口发起 HTTP 请求。
+func (n *Node) leaderHint() string {
+	httpAddr, _, _ := n.leaderAddrs()
+	return httpAddr
+}
+
+// errNoLeaderHint 在 leader 的 HTTP 地址未知时返回 ErrNotLeader，已知其 Raft 地址时写入错误信息供运维排查。
+func (n *Node) errNoLeaderHint() error {
+	if _, raftAddr, _ := n.leaderAddrs(); raftAddr != "" {
+		return fmt.Errorf("%w: leader %s has no registered http address", service.ErrNotLeader, raftAddr)
+	}
+	return fmt.Errorf("%w: no known leader", service.ErrNotLeader)
+}
+
+// checkpointLoop 在本节点为 leader 时周期性发布审计链检查点，为 follower 时向 leader 回报本地链在最新检查点处的哈希。
+func (n *Node) checkpointLoop(stop <-chan struct{}) {
+	ticker := time.NewTicker(n.cfg.CheckpointInterval)
+	defer ticker.Stop()
+	client := &http.Client{Timeout: 5 * time.Second}
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if n.raftNode.State() != raft.Leader {
+				if err := n.reportCheckpoint(client); err != nil {
+					log.Printf("report audit checkpoint failed: %v", err)
+				}
+				continue
+			}
+			if d := n.auditSvc.Divergence(); d != nil {
+				// 本地审计链已分叉，不再签发检查点，把 leader 让给其它副本
+				log.Printf("audit chain diverged at %d, transferring leadership", d.Index)
+				if err := n.raftNode.LeadershipTransfer().Error(); err != nil {
+					log.Printf("leadership transfer failed: %v", err)
+				}
+				continue
+			}
+			if err := n.publishCheckpoint(); err != nil {
+				log.Printf("publish audit checkpoint failed: %v", err)
+			}
+		}
+	}
+}
+
+// publishCheckpoint 对当前审计链头签名，并通过 Raft 复制到所有副本；链头未前进时跳过。
+func (n *Node) publishCheckpoint() error {
+	index, lastHash, err := n.auditSvc.Head()
+	if err != nil || index == 0 {
+		return err
+	}
+	latest, err := n.auditSvc.LatestCheckpoint()
+	if err != nil {
 		return err
 	}
-	if respErr, ok := future.Response().(error); ok && respErr != nil {
-		return respErr
+	if latest != nil && latest.Index >= index {
+		return nil
+	}
+	cp := &types.Checkpoint{
+		Index:     index,
+		LastHash:  hex.EncodeToString(lastHash[:]),
+		Term:      n.raftNode.CurrentTerm(),
+		Timestamp: time.Now().Unix(),
+	}
+	sig, signer, err := n.sign(audit.CheckpointPayload(cp.Index, lastHash, cp.Term, cp.Timestamp))
+	if err != nil {
+		return err
+	}
+	cp.Signer = signer
+	cp.Signature = hex.EncodeToString(sig)
+	_, err = n.propose(raftCommand{Type: commandCheckpoint, Checkpoint: cp})
+	return err
+}
+
+// reportCheckpoint 对本地审计链在最新检查点索引处的哈希签名，提交给 leader 的 /raft/report。
+// 已分叉的副本同样回报，leader 以此判断分叉的是否是它自己。
+func (n *Node) reportCheckpoint(client *http.Client) error {
+	leader, _, isLeader := n.leaderAddrs()
+	if isLeader || leader == "" {
+		return nil
+	}
+	cp, err := n.auditSvc.LatestCheckpoint()
+	if err != nil || cp == nil {
+		return err
+	}
+	local, err := n.auditSvc.LocalHash(cp.Index)
+	if err != nil {
+		return err
+	}
+	r := types.CheckpointReport{
+		NodeID:    n.cfg.NodeID,
+		Index:     cp.Index,
+		LocalHash: local,
+		Timestamp: time.Now().Unix(),
+	}
+	sig, signer, err := n.sign(audit.ReportPayload(r.NodeID, r.Index, r.LocalHash, r.Timestamp))
+	if err != nil {
+		return err
+	}
+	r.Signer = signer
+	r.Signature = hex.EncodeToString(sig)
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Post(fmt.Sprintf("http://%s/raft/report", leader), "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("leader %s: %s", leader, bytes.TrimSpace(msg))
 	}
 	return nil
 }
 
+// handleCheckpointReport 在 leader 上登记 follower 的检查点回报，按当前配置中有投票权的节点计算多数派。
+func (n *Node) handleCheckpointReport(r *types.CheckpointReport) error {
+	if n.raftNode == nil {
+		return errors.New("raft not initialized")
+	}
+	if n.raftNode.State() != raft.Leader {
+		return service.ErrNotLeader
+	}
+	cf := n.raftNode.GetConfiguration()
+	if err := cf.Error(); err != nil {
+		return raftError(err)
+	}
+	var voters []string
+	for _, srv := range cf.Configuration().Servers {
+		if srv.Suffrage == raft.Voter {
+			voters = append(voters, string(srv.ID))
+		}
+	}
+	return n.auditSvc.RecordReport(r, voters)
+}
+
+// propose 将命令序列化后提交给 Raft 日志，并返回 FSM 的执行结果。
+func (n *Node) propose(cmd raftCommand) (*applyResponse, error) {
+	if n.raftNode == nil {
+		return nil, errors.New("raft not initialized")
+	}
+	if d := n.auditSvc.Divergence(); d != nil {
+		return nil, fmt.Errorf("%w at index %d, writes disabled", service.ErrAuditDiverged, d.Index)
+	}
+	payload, err := json.Marshal(cmd)
+	if err != nil {
+		return nil, err
+	}
+	future := n.raftNode.Apply(payload, 5*time.Second)
+	if err := future.Error(); err != nil {
+		return nil, raftError(err)
+	}
+	switch resp := future.Response().(type) {
+	case *applyResponse:
+		return resp, resp.Err
+	case error:
+		return nil, resp
+	}
+	return nil, nil
+}
+
+// raftError 把 Raft 返回的错误转换为 service 中可重试的错误类型，其它错误原样返回。
+func raftError(err error) error {
+	switch {
+	case errors.Is(err, raft.ErrNotLeader), errors.Is(err, raft.ErrLeadershipLost),
+		errors.Is(err, raft.ErrLeadershipTransferInProgress):
+		return fmt.Errorf("%w: %v", service.ErrNotLeader, err)
+	case errors.Is(err, raft.ErrEnqueueTimeout):
+		return fmt.Errorf("%w: %v", service.ErrTimeout, err)
+	}
+	return err
+}
+
 // joinCluster 尝试联系集群节点完成加入操作。
+// 非 leader 节点在 X-Raft-Leader 中给出 leader 的 HTTP 地址，加入请求随之重试；
+// 响应体带 raft_leader 时该提示只是 Raft 地址，不能用来发 HTTP 请求，等下一轮重新询问配置的节点。
 func (n *Node) joinCluster() error {
 	if len(n.cfg.RaftPeers) == 0 {
 		return errors.New("raft_peers required for join")
 	}
-	body, _ := json.Marshal(joinRequest{NodeID: n.cfg.NodeID, RaftAddress: n.cfg.RaftBind})
-	visited := map[string]bool{}
-	queue := append([]string{}, n.cfg.RaftPeers...)
+	body, _ := json.Marshal(joinRequest{NodeID: n.cfg.NodeID, RaftAddress: n.cfg.RaftBind, HTTPAddress: n.cfg.HTTPAdvertise, GenesisHash: n.genesisHash, Identity: n.identityAddr})
 	client := &http.Client{Timeout: 5 * time.Second}
-	for len(queue) > 0 {
-		peer := queue[0]
-		queue = queue[1:]
-		if visited[peer] {
-			continue
-		}
-		visited[peer] = true
-		url := fmt.Sprintf("http://%s/raft/join", peer)
-		resp, err := client.Post(url, "application/json", bytes.NewReader(body))
-		if err != nil {
-			log.Printf("join request to %s failed: %v", url, err)
-			continue
+	for attempt := 0; attempt < joinAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(joinRetryInterval)
 		}
-		if resp.StatusCode == http.StatusOK {
-			resp.Body.Close()
-			return nil
-		}
-		leader := resp.Header.Get("X-Raft-Leader")
-		resp.Body.Close()
-		if leader != "" && !visited[leader] {
-			queue = append(queue, leader)
+		visited := map[string]bool{}
+		queue := append([]string{}, n.cfg.RaftPeers...)
+		for len(queue) > 0 {
+			peer := queue[0]
+			queue = queue[1:]
+			if visited[peer] {
+				continue
+			}
+			visited[peer] = true
+			joined, leader, err := postJoin(client, peer, body)
+			if err != nil {
+				return err
+			}
+			if joined {
+				return nil
+			}
+			if leader != "" && !visited[leader] {
+				queue = append(queue, leader)
+			}
 		}
 	}
 	return errors.New("failed to join raft cluster")
 }
 
-// handleJoinRequest 响应其它节点提交的 join 请求。
-func (n *Node) handleJoinRequest(nodeID, raftAddr string) (string, error) {
+const (
+	// joinAttempts 为加入集群的轮数，每轮依次询问配置的节点；新 leader 登记 HTTP 地址前的请求会失败
+	joinAttempts      = 5
+	joinRetryInterval = time.Second
+)
+
+// postJoin 向 peer 提交加入请求，返回是否已加入以及可继续重试的 leader HTTP 地址。
+// 只有不可重试的拒绝（创世配置不一致）才返回错误。
+func postJoin(client *http.Client, peer string, body []byte) (bool, string, error) {
+	url := fmt.Sprintf("http://%s/raft/join", peer)
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("join request to %s failed: %v", url, err)
+		return false, "", nil
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, "", nil
+	case http.StatusConflict:
+		// 创世配置不一致，换其它节点重试也不会成功
+		return false, "", fmt.Errorf("join refused by %s: %s", peer, bytes.TrimSpace(msg))
+	}
+	var hint struct {
+		RaftLeader string `json:"raft_leader"`
+	}
+	if json.Unmarshal(msg, &hint) == nil && hint.RaftLeader != "" {
+		log.Printf("join request to %s: leader %s has no registered http address yet", url, hint.RaftLeader)
+		return false, "", nil
+	}
+	return false, resp.Header.Get("X-Raft-Leader"), nil
+}
+
+// ensureRead 在读取本地状态前确认满足请求的一致性级别；本节点不是 leader 时返回 leader 地址与错误。
+// leader 级别通过 VerifyLeader 向多数派确认自己仍是 leader；linearizable 级别再提交一个 Barrier，
+// 等待此前所有日志（包括新 leader 上任时的 no-op）都已应用到 FSM，之后的读取不会早于任何已确认的写入。
+func (n *Node) ensureRead(level string) (string, error) {
+	if n.raftNode == nil {
+		return "", errors.New("raft not initialized")
+	}
+	if level == api.ReadStale {
+		return "", nil
+	}
+	if n.raftNode.State() != raft.Leader {
+		leader := n.leaderHint()
+		if leader == "" {
+			return "", n.errNoLeaderHint()
+		}
+		return leader, service.ErrNotLeader
+	}
+	if err := n.raftNode.VerifyLeader().Error(); err != nil {
+		return n.leaderHint(), fmt.Errorf("verify leader: %w", raftError(err))
+	}
+	if level == api.ReadLinearizable {
+		if err := n.raftNode.Barrier(5 * time.Second).Error(); err != nil {
+			return n.leaderHint(), fmt.Errorf("read barrier: %w", raftError(err))
+		}
+	}
+	return "", nil
+}
+
+// handleJoinRequest 响应其它节点提交的 join 请求，并登记新节点的 HTTP 地址与身份地址。
+// genesisHash 非空时必须与集群的创世哈希一致，否则拒绝加入。
+func (n *Node) handleJoinRequest(nodeID, raftAddr, httpAddr, genesisHash, identity string) (string, error) {
 	if n.raftNode == nil {
 		return "", errors.New("raft not initialized")
 	}
 	if n.raftNode.State() != raft.Leader {
-		leader := string(n.raftNode.Leader())
+		leader := n.leaderHint()
 		if leader == "" {
-			return "", errors.New("no leader")
+			return "", n.errNoLeaderHint()
+		}
+		return leader, service.ErrNotLeader
+	}
+	if genesisHash != "" {
+		clusterHash, err := n.accountSvc.GenesisHash()
+		if err != nil {
+			return "", err
+		}
+		if clusterHash == "" {
+			clusterHash = n.genesisHash
+		}
+		if clusterHash != genesisHash {
+			return "", fmt.Errorf("%w: cluster %q, node %s has %q", store.ErrGenesisMismatch, clusterHash, nodeID, genesisHash)
 		}
-		return leader, errors.New("not leader")
 	}
 	future := n.raftNode.AddVoter(raft.ServerID(nodeID), raft.ServerAddress(raftAddr), 0, 0)
 	if err := future.Error(); err != nil {
-		return "", err
+		return "", raftError(err)
+	}
+	if httpAddr != "" {
+		if err := n.proposeSetPeer(raftAddr, httpAddr); err != nil {
+			return "", err
+		}
+	}
+	if identity != "" {
+		if _, err := crypto.HexToPublicKey(identity); err != nil {
+			return "", fmt.Errorf("invalid identity: %w", err)
+		}
+		if err := n.proposeSetIdentity(nodeID, identity); err != nil {
+			return "", err
+		}
 	}
 	return "", nil
 }
@@ -261,32 +804,86 @@ func (n *Node) handleLeaveRequest(nodeID string) (string, error) {
 		return "", errors.New("raft not initialized")
 	}
 	if n.raftNode.State() != raft.Leader {
-		leader := string(n.raftNode.Leader())
+		leader := n.leaderHint()
 		if leader == "" {
-			return "", errors.New("no leader")
+			return "", n.errNoLeaderHint()
+		}
+		return leader, service.ErrNotLeader
+	}
+	var raftAddr string
+	if cf := n.raftNode.GetConfiguration(); cf.Error() == nil {
+		for _, srv := range cf.Configuration().Servers {
+			if srv.ID == raft.ServerID(nodeID) {
+				raftAddr = string(srv.Address)
+			}
 		}
-		return leader, errors.New("not leader")
 	}
 	future := n.raftNode.RemoveServer(raft.ServerID(nodeID), 0, 0)
 	if err := future.Error(); err != nil {
+		return "", raftError(err)
+	}
+	if raftAddr != "" {
+		if err := n.proposeSetPeer(raftAddr, ""); err != nil {
+			return "", err
+		}
+	}
+	// 移除的节点不能再签发被接受的检查点
+	if err := n.proposeSetIdentity(nodeID, ""); err != nil {
 		return "", err
 	}
 	return "", nil
 }
 
+// sign 使用节点身份私钥对载荷签名，返回签名与签名者地址。
+func (n *Node) sign(payload []byte) ([]byte, string, error) {
+	sig, err := crypto.Sign(n.identity, payload)
+	if err != nil {
+		return nil, "", err
+	}
+	return sig, n.identityAddr, nil
+}
+
 // raftStatus 返回当前节点的 Raft 状态信息。
 func (n *Node) raftStatus() map[string]interface{} {
 	if n.raftNode == nil {
 		return map[string]interface{}{"state": "not_initialized"}
 	}
 	stats := n.raftNode.Stats()
+	creator, err := n.accountSvc.Creator()
+	if err != nil {
+		log.Printf("load creator failed: %v", err)
+	}
+	genesisHash, err := n.accountSvc.GenesisHash()
+	if err != nil {
+		log.Printf("load genesis hash failed: %v", err)
+	}
+	peers, err := n.store.ListPeers()
+	if err != nil {
+		log.Printf("list peers failed: %v", err)
+	}
+	identities, err := n.auditSvc.Identities()
+	if err != nil {
+		log.Printf("list identities failed: %v", err)
+	}
 	return map[string]interface{}{
 		"node_id":        n.cfg.NodeID,
+		"chain_id":       n.cfg.ChainID,
+		"identity":       n.identityAddr,
 		"state":          n.raftNode.State().String(),
 		"leader":         string(n.raftNode.Leader()),
 		"term":           stats["term"],
 		"last_log_index": stats["last_log_index"],
 		"applied_index":  stats["applied_index"],
+		"creator":        creator,
+		"genesis_hash":   genesisHash,
+		// 已登记的集群成员：Raft 地址 -> HTTP 地址
+		"peers": peers,
+		// 已登记的节点身份：节点 ID -> 身份地址，只有这些身份签发的检查点会被接受
+		"identities": identities,
+		// 后台全量审计校验进度，error 非空表示本地审计链校验失败
+		"audit_verification": n.verifier.Progress(),
+		// 本地审计链与集群检查点分叉时非空，此时节点拒绝写入
+		"audit_divergence": n.auditSvc.Divergence(),
 	}
 }
 
@@ -301,33 +898,83 @@ func (f *fsm) Apply(logEntry *raft.Log) interface{} {
 		if cmd.Transaction == nil {
 			return errors.New("nil transaction")
 		}
-		return f.txSvc.Apply(*cmd.Transaction)
+		// 任期取自提交该日志的 Raft 条目：提议后发生 leader 切换时，提议时读到的任期可能与实际提交的不同
+		meta := store.AuditMeta{Timestamp: cmd.ProposedAt, Term: logEntry.Term, RaftIndex: logEntry.Index}
+		receipt, err := f.txSvc.Apply(*cmd.Transaction, meta, cmd.PayloadVersion)
+		if receipt == nil {
+			// 回执未能落盘属于存储故障而非业务拒绝，记录后交给调用方
+			log.Printf("apply transaction at index %d: %v", logEntry.Index, err)
+			return err
+		}
+		return &applyResponse{Receipt: receipt, Err: err}
+	case commandRegisterAccount:
+		if cmd.Address == "" {
+			return errors.New("empty address")
+		}
+		role, err := f.accountSvc.Register(cmd.Address, cmd.Role)
+		return &applyResponse{Role: role, Err: err}
+	case commandSetRole:
+		if cmd.Address == "" {
+			return errors.New("empty address")
+		}
+		if cmd.Signer != "" {
+			return f.accountSvc.GrantRoleSigned(cmd.Signer, cmd.Nonce, cmd.Address, cmd.Role)
+		}
+		return f.accountSvc.GrantRole(cmd.Address, cmd.Role)
+	case commandCheckpoint:
+		if cmd.Checkpoint == nil {
+			return errors.New("nil checkpoint")
+		}
+		if err := f.auditSvc.RecordCheckpoint(cmd.Checkpoint, logEntry.Term); err != nil {
+			log.Printf("audit checkpoint %d rejected: %v", cmd.Checkpoint.Index, err)
+			return err
+		}
+		return nil
+	case commandSetPeer:
+		if cmd.RaftAddress == "" {
+			return errors.New("empty raft address")
+		}
+		return f.store.SetPeer(cmd.RaftAddress, cmd.HTTPAddress)
+	case commandSetIdentity:
+		if cmd.NodeID == "" {
+			return errors.New("empty node id")
+		}
+		return f.auditSvc.SetIdentity(cmd.NodeID, cmd.Identity)
+	case commandGenesis:
+		if cmd.Genesis == nil {
+			return errors.New("nil genesis")
+		}
+		if err := f.accountSvc.ApplyGenesis(cmd.Genesis); err != nil {
+			log.Printf("genesis rejected: %v", err)
+			return err
+		}
+		return nil
 	default:
 		return fmt.Errorf("unknown command: %s", cmd.Type)
 	}
 }
 
-// Snapshot 使用 Badger 自带备份生成快照。
+// Snapshot 使用 Badger 备份生成快照，不含副本本地状态。
 func (f *fsm) Snapshot() (raft.FSMSnapshot, error) {
-	return &badgerSnapshot{db: f.db}, nil
+	return &badgerSnapshot{store: f.store}, nil
 }
 
-// Restore 清空 Badger 并从快照恢复。
+// Restore 用快照替换复制状态，并按恢复后的数据重新加载审计分叉状态。
 func (f *fsm) Restore(rc io.ReadCloser) error {
 	defer rc.Close()
-	if err := f.db.DropAll(); err != nil {
+	if err := f.store.Restore(rc); err != nil {
 		return err
 	}
-	return f.db.Load(rc, 10)
+	return f.auditSvc.CheckLatestCheckpoint()
 }
 
 // badgerSnapshot 负责将 Badger 快照写入 Raft sink。
 type badgerSnapshot struct {
-	db *badger.DB
+	store *store.Store
 }
 
 func (s *badgerSnapshot) Persist(sink raft.SnapshotSink) error {
-	if _, err := s.db.Backup(sink, 0); err != nil {
+	if err := s.store.Backup(sink); err != nil {
 		sink.Cancel()
 		return err
 	}
@@ -0,0 +1,171 @@
+package node
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"distributed_ledger_go/internal/service"
+	"distributed_ledger_go/internal/store"
+	"distributed_ledger_go/pkg/audit"
+	"distributed_ledger_go/pkg/types"
+
+	badger "github.com/dgraph-io/badger/v3"
+	"github.com/hashicorp/raft"
+)
+
+func newTestFSM(t *testing.T) (*fsm, *badger.DB) {
+	t.Helper()
+	db, err := badger.Open(badger.DefaultOptions(t.TempDir()).WithLogger(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	s := store.NewStore(db)
+	auditSvc := service.NewAuditService(s)
+	return &fsm{
+		txSvc:      service.NewTransactionService(s, nil, auditSvc),
+		accountSvc: service.NewAccountService(s),
+		auditSvc:   auditSvc,
+		store:      s,
+	}, db
+}
+
+func applyCommand(t *testing.T, f *fsm, index uint64, cmd raftCommand) interface{} {
+	t.Helper()
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f.Apply(&raft.Log{Index: index, Term: 1, Data: data})
+}
+
+func transactionCommand(tx types.Transaction) raftCommand {
+	return raftCommand{Type: commandTransaction, Transaction: &tx, ProposedAt: 1000, PayloadVersion: audit.CurrentPayloadVersion}
+}
+
+// 被拒绝的交易返回携带失败回执与拒绝原因的 applyResponse，存储故障则直接返回错误
+func TestFSMApplyTransactionResponse(t *testing.T) {
+	f, db := newTestFSM(t)
+	if resp, ok := applyCommand(t, f, 1, raftCommand{Type: commandRegisterAccount, Address: "alice", Role: types.RoleUser}).(*applyResponse); !ok || resp.Err != nil {
+		t.Fatalf("register = %+v", resp)
+	}
+
+	res := applyCommand(t, f, 2, transactionCommand(types.Transaction{Type: types.TxTypeTransfer, Sender: "alice", Receiver: "alice", Amount: 5, Nonce: 1}))
+	resp, ok := res.(*applyResponse)
+	if !ok {
+		t.Fatalf("rejected transaction returned %T %v, want *applyResponse", res, res)
+	}
+	if !errors.Is(resp.Err, store.ErrInsufficientBalance) {
+		t.Fatalf("Err = %v, want ErrInsufficientBalance", resp.Err)
+	}
+	if resp.Receipt == nil || resp.Receipt.Status != types.ReceiptStatusFailed || resp.Receipt.RaftIndex != 2 {
+		t.Fatalf("Receipt = %+v, want failed receipt at index 2", resp.Receipt)
+	}
+
+	res = applyCommand(t, f, 3, transactionCommand(types.Transaction{Type: types.TxTypeMint, Sender: "alice", Receiver: "alice", Amount: 5, Nonce: 1}))
+	if resp, ok := res.(*applyResponse); !ok || resp.Err != nil || resp.Receipt.Status != types.ReceiptStatusSuccess {
+		t.Fatalf("mint = %T %+v", res, res)
+	}
+
+	if err, ok := f.Apply(&raft.Log{Index: 4, Data: []byte("{")}).(error); !ok || err == nil {
+		t.Fatal("malformed command: expected error")
+	}
+	if err, ok := applyCommand(t, f, 5, raftCommand{Type: "bogus"}).(error); !ok || err == nil {
+		t.Fatal("unknown command: expected error")
+	}
+
+	db.Close()
+	res = applyCommand(t, f, 6, transactionCommand(types.Transaction{Type: types.TxTypeMint, Sender: "alice", Receiver: "alice", Amount: 5, Nonce: 2}))
+	if _, ok := res.(error); !ok {
+		t.Fatalf("storage failure returned %T %v, want error", res, res)
+	}
+}
+
+// 审计条目的任期取自提交该命令的 Raft 条目，而不是提议时读到的任期
+func TestFSMApplyStampsLogTerm(t *testing.T) {
+	f, _ := newTestFSM(t)
+	applyCommand(t, f, 1, raftCommand{Type: commandRegisterAccount, Address: "alice", Role: types.RoleUser})
+	data, err := json.Marshal(transactionCommand(types.Transaction{Type: types.TxTypeMint, Sender: "alice", Receiver: "alice", Amount: 5, Nonce: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, ok := f.Apply(&raft.Log{Index: 2, Term: 9, Data: data}).(*applyResponse); !ok || resp.Err != nil {
+		t.Fatalf("mint = %+v", resp)
+	}
+	last, _, err := f.auditSvc.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := f.auditSvc.GetEntry(last)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Term != 9 || e.RaftIndex != 2 {
+		t.Fatalf("entry term %d index %d, want 9 and 2", e.Term, e.RaftIndex)
+	}
+}
+
+func TestPostJoinLeaderHints(t *testing.T) {
+	// notLeader 模拟非 leader 节点对 join 请求的 503 响应
+	notLeader := func(body string) string {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Raft-Leader", "10.0.0.1:7000")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(body))
+		}))
+		t.Cleanup(srv.Close)
+		return strings.TrimPrefix(srv.URL, "http://")
+	}
+	client := &http.Client{}
+
+	peer := notLeader(`{"error":"not leader","code":"not_leader"}`)
+	joined, leader, err := postJoin(client, peer, nil)
+	if err != nil || joined || leader != "10.0.0.1:7000" {
+		t.Fatalf("http hint: joined=%v leader=%q err=%v", joined, leader, err)
+	}
+	// leader 尚未登记 HTTP 地址，提示只是 Raft 地址，不能据此重试
+	peer = notLeader(`{"error":"not leader","code":"not_leader","raft_leader":"10.0.0.1:7000"}`)
+	joined, leader, err = postJoin(client, peer, nil)
+	if err != nil || joined || leader != "" {
+		t.Fatalf("raft-only hint: joined=%v leader=%q err=%v", joined, leader, err)
+	}
+}
+
+func TestFSMRestoreReloadsAuditState(t *testing.T) {
+	src, _ := newTestFSM(t)
+	snap, err := src.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := &memorySink{}
+	if err := snap.Persist(sink); err != nil {
+		t.Fatal(err)
+	}
+
+	dst, _ := newTestFSM(t)
+	if _, err := dst.store.SaveDivergence(&types.AuditDivergence{Index: 4, Source: service.DivergenceQuorum}); err != nil {
+		t.Fatal(err)
+	}
+	if err := dst.Restore(io.NopCloser(bytes.NewReader(sink.Bytes()))); err != nil {
+		t.Fatal(err)
+	}
+	// 本节点的分叉记录在恢复后保留，内存状态与磁盘一致
+	if d := dst.auditSvc.Divergence(); d == nil || d.Index != 4 {
+		t.Fatalf("divergence after restore = %+v", d)
+	}
+}
+
+// memorySink 把快照写入内存
+type memorySink struct {
+	bytes.Buffer
+}
+
+func (s *memorySink) ID() string    { return "test" }
+func (s *memorySink) Cancel() error { return nil }
+func (s *memorySink) Close() error  { return nil }
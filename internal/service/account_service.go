@@ -2,7 +2,8 @@ package service
 
 import (
 	"distributed_ledger_go/internal/store"
-	"distributed_ledger_go/internal/types"
+	"distributed_ledger_go/pkg/txhash"
+	"distributed_ledger_go/pkg/types"
 )
 
 // 封装账户层面的读写操作。
@@ -14,15 +15,24 @@ func NewAccountService(s *store.Store) *AccountService {
 	return &AccountService{store: s}
 }
 
-// 在 KV 中注册账户，并按需设置初始角色。
-func (svc *AccountService) Register(address string, role string) error {
-	if err := svc.store.RegisterAccount(address); err != nil {
-		return err
-	}
-	if role != "" && role != types.RoleUser {
-		return svc.store.SetRole(address, role)
-	}
-	return nil
+// 在 KV 中注册账户并返回其角色；新注册的账户为普通用户，role 为空或 CREATOR 只出现在旧版本日志中。
+func (svc *AccountService) Register(address string, role string) (string, error) {
+	return svc.store.RegisterWithRole(address, role)
+}
+
+// 写入创世状态，创世哈希由配置内容计算，与已写入的不一致时返回 store.ErrGenesisMismatch。
+func (svc *AccountService) ApplyGenesis(g *types.Genesis) error {
+	return svc.store.ApplyGenesis(g, txhash.GenesisHash(g))
+}
+
+// 返回已写入的创世哈希，未使用创世配置时为空。
+func (svc *AccountService) GenesisHash() (string, error) {
+	return svc.store.GenesisHash()
+}
+
+// 返回创世者地址，尚未产生创世者时为空。
+func (svc *AccountService) Creator() (string, error) {
+	return svc.store.Creator()
 }
 
 // 调整账户角色权限。
@@ -30,6 +40,11 @@ func (svc *AccountService) GrantRole(address, role string) error {
 	return svc.store.SetRole(address, role)
 }
 
+// 按创世者签名调整账户角色，同时消耗创世者的 nonce。
+func (svc *AccountService) GrantRoleSigned(signer string, nonce uint64, address, role string) error {
+	return svc.store.SetRoleSigned(signer, nonce, address, role)
+}
+
 // 读取账户详情。
 func (svc *AccountService) GetAccount(address string) (*types.Account, error) {
 	return svc.store.GetAccount(address)
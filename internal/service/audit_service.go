@@ -1,19 +1,46 @@
 package service
 
 import (
-	"encoding/json"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
 
 	"distributed_ledger_go/internal/store"
-	"distributed_ledger_go/internal/types"
+	"distributed_ledger_go/pkg/audit"
+	"distributed_ledger_go/pkg/types"
+)
+
+// ErrAuditDiverged 表示本地审计链与集群检查点不一致，节点拒绝写入。
+var ErrAuditDiverged = errors.New("local audit chain diverged from cluster")
+
+// ErrInvalidReport 表示副本检查点回报的签名或签名者无效。
+var ErrInvalidReport = errors.New("invalid checkpoint report")
+
+// AuditDivergence 记录本地审计链与集群不一致的位置。
+type AuditDivergence = types.AuditDivergence
+
+// 分叉来源
+const (
+	DivergenceCheckpoint = "checkpoint"
+	DivergenceQuorum     = "quorum"
 )
 
 // 封装审计链读写操作。
 type AuditService struct {
 	store *store.Store
+
+	mu         sync.Mutex
+	divergence *AuditDivergence
+	// cleared 为运维清除分叉时的检查点索引，不再比对不超过它的检查点与回报
+	cleared uint64
+	// reports 为 leader 收到的各副本最近一次检查点回报，按节点 ID 索引
+	reports map[string]*types.CheckpointReport
 }
 
 func NewAuditService(s *store.Store) *AuditService {
-	return &AuditService{store: s}
+	return &AuditService{store: s, reports: map[string]*types.CheckpointReport{}}
 }
 
 // 将交易序列化并写入审计链。
@@ -21,13 +48,23 @@ func (svc *AuditService) AppendTransaction(tx types.Transaction) (*types.Entry,
 	if svc.store == nil {
 		return nil, nil
 	}
-	payload, err := json.Marshal(tx)
+	payload, err := svc.EncodeTransaction(tx, audit.CurrentPayloadVersion)
 	if err != nil {
 		return nil, err
 	}
 	return svc.store.Append(payload)
 }
 
+// 按指定版本生成写入审计链的交易载荷，版本 1 起为 pkg/audit 定义的规范二进制编码。
+func (svc *AuditService) EncodeTransaction(tx types.Transaction, version byte) ([]byte, error) {
+	return audit.EncodePayload(version, tx)
+}
+
+// 从审计载荷还原交易，兼容升级前的 JSON 条目。
+func (svc *AuditService) DecodeTransaction(payload []byte) (types.Transaction, error) {
+	return audit.DecodeTransaction(payload)
+}
+
 // 按索引读取审计条目。
 func (svc *AuditService) GetEntry(index uint64) (*types.Entry, error) {
 	if svc.store == nil {
@@ -50,3 +87,318 @@ func (svc *AuditService) ListEntries() ([]*types.Entry, error) {
 	}
 	return svc.store.ListEntries()
 }
+
+// 返回审计链最新索引与哈希。
+func (svc *AuditService) Head() (uint64, [32]byte, error) {
+	if svc.store == nil {
+		return 0, [32]byte{}, nil
+	}
+	return svc.store.Head()
+}
+
+// 返回第一个时间戳不早于 ts 的条目索引，不存在时返回链头索引 + 1。
+func (svc *AuditService) SearchByTime(ts int64) (uint64, error) {
+	if svc.store == nil {
+		return 0, nil
+	}
+	return svc.store.SearchEntryByTime(ts)
+}
+
+// 从 from 开始分页读取审计条目。
+func (svc *AuditService) ListRange(from uint64, limit int) ([]*types.Entry, error) {
+	if svc.store == nil {
+		return nil, nil
+	}
+	return svc.store.ListEntriesRange(from, limit)
+}
+
+// 从 from 开始流式遍历审计条目，不会一次性载入整条链。
+func (svc *AuditService) Iterate(from uint64, fn func(*types.Entry) error) error {
+	if svc.store == nil {
+		return nil
+	}
+	return svc.store.IterateEntries(from, fn)
+}
+
+// 为升级前已有的审计条目补建 Merkle 累加器。
+func (svc *AuditService) EnsureMerkle() error {
+	if svc.store == nil {
+		return nil
+	}
+	return svc.store.EnsureMerkle()
+}
+
+// 生成审计条目的包含性证明，treeSize 为 0 时使用当前链头。
+// 返回证明路径、树大小与树根。
+func (svc *AuditService) InclusionProof(index, treeSize uint64) ([][32]byte, uint64, [32]byte, error) {
+	if svc.store == nil {
+		return nil, 0, [32]byte{}, nil
+	}
+	if treeSize == 0 {
+		last, _, err := svc.store.Head()
+		if err != nil {
+			return nil, 0, [32]byte{}, err
+		}
+		treeSize = last
+	}
+	proof, root, err := svc.store.InclusionProof(index, treeSize)
+	return proof, treeSize, root, err
+}
+
+// 生成从 oldSize 到 newSize 的一致性证明；newSize 为 0 时使用当前链头
+func (svc *AuditService) ConsistencyProof(oldSize, newSize uint64) ([][32]byte, uint64, [32]byte, [32]byte, error) {
+	if svc.store == nil {
+		return nil, 0, [32]byte{}, [32]byte{}, nil
+	}
+	if newSize == 0 {
+		last, _, err := svc.store.Head()
+		if err != nil {
+			return nil, 0, [32]byte{}, [32]byte{}, err
+		}
+		newSize = last
+	}
+	proof, oldRoot, newRoot, err := svc.store.ConsistencyProof(oldSize, newSize)
+	return proof, newSize, oldRoot, newRoot, err
+}
+
+// 记录经 Raft 提交的检查点：校验签名、签名者为已登记的节点身份以及任期后保存，
+// 若本地审计链在该索引处的哈希与检查点不一致则返回错误（检查点仍会保存，作为比对依据）。
+func (svc *AuditService) RecordCheckpoint(cp *types.Checkpoint, term uint64) error {
+	if svc.store == nil {
+		return nil
+	}
+	if err := audit.VerifyCheckpoint(cp); err != nil {
+		return err
+	}
+	if err := svc.store.CheckIdentity(cp.Signer); err != nil {
+		return err
+	}
+	if cp.Term != term {
+		return fmt.Errorf("checkpoint term %d does not match log term %d", cp.Term, term)
+	}
+	if _, err := svc.store.SaveCheckpoint(cp); err != nil {
+		return err
+	}
+	return svc.compareCheckpoint(cp)
+}
+
+// 对比本地审计链与检查点，不一致时记录分叉告警并返回 ErrAuditDiverged。
+func (svc *AuditService) compareCheckpoint(cp *types.Checkpoint) error {
+	if cp.Index <= svc.clearedIndex() {
+		return nil
+	}
+	local, err := svc.localHash(cp.Index)
+	if err != nil {
+		return fmt.Errorf("checkpoint %d: %w", cp.Index, err)
+	}
+	if local == cp.LastHash {
+		return nil
+	}
+	return svc.recordDivergence(&AuditDivergence{
+		Index:    cp.Index,
+		Expected: cp.LastHash,
+		Local:    local,
+		Term:     cp.Term,
+		Source:   DivergenceCheckpoint,
+		// 该路径在 fsm.Apply 中执行，取检查点中 leader 的时间戳而非本机时钟
+		DetectedAt: cp.Timestamp,
+	})
+}
+
+// 返回本地审计链在 index 处的条目哈希，本地链短于 index 时返回空串。
+func (svc *AuditService) localHash(index uint64) (string, error) {
+	lastIndex, _, err := svc.store.Head()
+	if err != nil {
+		return "", err
+	}
+	if index > lastIndex {
+		return "", nil
+	}
+	e, err := svc.store.GetEntry(index)
+	if err != nil {
+		return "", fmt.Errorf("read local audit entry: %w", err)
+	}
+	return hex.EncodeToString(e.EntryHash[:]), nil
+}
+
+// LocalHash 返回本地审计链在 index 处的条目哈希，供 follower 生成检查点回报。
+func (svc *AuditService) LocalHash(index uint64) (string, error) {
+	if svc.store == nil {
+		return "", nil
+	}
+	return svc.localHash(index)
+}
+
+// 持久化分叉记录（只保留最先发现的位置）并返回 ErrAuditDiverged，重启后写入依然被拒绝。
+func (svc *AuditService) recordDivergence(d *AuditDivergence) error {
+	if _, err := svc.store.SaveDivergence(d); err != nil {
+		return err
+	}
+	svc.mu.Lock()
+	if svc.divergence == nil {
+		svc.divergence = d
+	}
+	svc.mu.Unlock()
+	log.Printf("ALARM: audit chain diverged at index %d (%s): expected %s, local %q", d.Index, d.Source, d.Expected, d.Local)
+	return fmt.Errorf("index %d: %w", d.Index, ErrAuditDiverged)
+}
+
+func (svc *AuditService) clearedIndex() uint64 {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	return svc.cleared
+}
+
+// 启动时恢复持久化的分叉记录，并用最新检查点比对本地审计链，重启后依然能发现分叉。
+func (svc *AuditService) CheckLatestCheckpoint() error {
+	if svc.store == nil {
+		return nil
+	}
+	d, cleared, err := svc.store.LoadDivergence()
+	if err != nil {
+		return err
+	}
+	svc.mu.Lock()
+	svc.divergence, svc.cleared = d, cleared
+	svc.mu.Unlock()
+	if d != nil {
+		log.Printf("ALARM: audit chain divergence at index %d recorded at %d, writes disabled until cleared", d.Index, d.DetectedAt)
+	}
+	cp, err := svc.store.LatestCheckpoint()
+	if err != nil || cp == nil {
+		return err
+	}
+	if err := svc.compareCheckpoint(cp); err != nil && !errors.Is(err, ErrAuditDiverged) {
+		return err
+	}
+	return nil
+}
+
+// RecordReport 由 leader 调用，登记副本的检查点回报；voters 为当前有投票权的节点 ID（含 leader 自己）。
+// 回报须由该节点登记过的身份签名。同一索引上与本地哈希不同且彼此一致的回报达到多数派时，
+// 说明分叉的是 leader 自己，记录分叉并返回 ErrAuditDiverged。
+func (svc *AuditService) RecordReport(r *types.CheckpointReport, voters []string) error {
+	if svc.store == nil {
+		return nil
+	}
+	if err := audit.VerifyReport(r); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidReport, err)
+	}
+	identities, err := svc.store.ListIdentities()
+	if err != nil {
+		return err
+	}
+	if identities[r.NodeID] != r.Signer {
+		return fmt.Errorf("%w: %s is not the registered identity of %s", ErrInvalidReport, r.Signer, r.NodeID)
+	}
+	svc.mu.Lock()
+	svc.reports[r.NodeID] = r
+	votes := map[string]int{}
+	for _, id := range voters {
+		if rep, ok := svc.reports[id]; ok && rep.Index == r.Index {
+			votes[rep.LocalHash]++
+		}
+	}
+	cleared := svc.cleared
+	svc.mu.Unlock()
+	if r.Index <= cleared {
+		return nil
+	}
+	local, err := svc.localHash(r.Index)
+	if err != nil {
+		return fmt.Errorf("report %d: %w", r.Index, err)
+	}
+	quorum := len(voters)/2 + 1
+	for hash, n := range votes {
+		if hash == local || n < quorum {
+			continue
+		}
+		var term uint64
+		if cps, err := svc.store.ListCheckpoints(r.Index, 1); err == nil && len(cps) == 1 && cps[0].Index == r.Index {
+			term = cps[0].Term
+		}
+		return svc.recordDivergence(&AuditDivergence{
+			Index:      r.Index,
+			Expected:   hash,
+			Local:      local,
+			Term:       term,
+			Source:     DivergenceQuorum,
+			DetectedAt: r.Timestamp,
+		})
+	}
+	return nil
+}
+
+// 返回首次发现的分叉信息，未分叉时返回 nil。
+func (svc *AuditService) Divergence() *AuditDivergence {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	if svc.divergence == nil {
+		return nil
+	}
+	d := *svc.divergence
+	return &d
+}
+
+// ClearDivergence 供运维在修复副本后清除分叉记录、恢复写入，返回被清除的记录（未分叉时为 nil）。
+// 不超过当前最新检查点与分叉位置的检查点和回报此后不再比对，之后的检查点仍会重新检测分叉。
+func (svc *AuditService) ClearDivergence() (*AuditDivergence, error) {
+	if svc.store == nil {
+		return nil, nil
+	}
+	d := svc.Divergence()
+	watermark := svc.clearedIndex()
+	if d != nil && d.Index > watermark {
+		watermark = d.Index
+	}
+	cp, err := svc.store.LatestCheckpoint()
+	if err != nil {
+		return nil, err
+	}
+	if cp != nil && cp.Index > watermark {
+		watermark = cp.Index
+	}
+	if err := svc.store.ClearDivergence(watermark); err != nil {
+		return nil, err
+	}
+	svc.mu.Lock()
+	svc.divergence, svc.cleared = nil, watermark
+	svc.reports = map[string]*types.CheckpointReport{}
+	svc.mu.Unlock()
+	if d != nil {
+		log.Printf("audit chain divergence at index %d cleared by operator (watermark %d)", d.Index, watermark)
+	}
+	return d, nil
+}
+
+// 从审计索引 from 开始分页读取检查点。
+func (svc *AuditService) ListCheckpoints(from uint64, limit int) ([]*types.Checkpoint, error) {
+	if svc.store == nil {
+		return nil, nil
+	}
+	return svc.store.ListCheckpoints(from, limit)
+}
+
+// 登记节点身份地址，identity 为空时删除。
+func (svc *AuditService) SetIdentity(nodeID, identity string) error {
+	if svc.store == nil {
+		return nil
+	}
+	return svc.store.SetIdentity(nodeID, identity)
+}
+
+// 返回已登记的节点身份：节点 ID -> 身份地址。
+func (svc *AuditService) Identities() (map[string]string, error) {
+	if svc.store == nil {
+		return nil, nil
+	}
+	return svc.store.ListIdentities()
+}
+
+// 返回最新的检查点，不存在时返回 nil。
+func (svc *AuditService) LatestCheckpoint() (*types.Checkpoint, error) {
+	if svc.store == nil {
+		return nil, nil
+	}
+	return svc.store.LatestCheckpoint()
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"distributed_ledger_go/internal/store"
+	"distributed_ledger_go/pkg/audit"
+	"distributed_ledger_go/pkg/crypto"
+	"distributed_ledger_go/pkg/types"
+
+	badger "github.com/dgraph-io/badger/v3"
+)
+
+func openTestStore(t *testing.T, dir string) (*store.Store, func()) {
+	t.Helper()
+	db, err := badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store.NewStore(db), func() { db.Close() }
+}
+
+// signedReport 生成节点 nodeID 对 index 处哈希 hash 的回报，并把其身份登记到 s
+func signedReport(t *testing.T, s *store.Store, nodeID string, index uint64, hash string) *types.CheckpointReport {
+	t.Helper()
+	priv, addr, err := crypto.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetIdentity(nodeID, addr); err != nil {
+		t.Fatal(err)
+	}
+	r := &types.CheckpointReport{NodeID: nodeID, Index: index, LocalHash: hash, Timestamp: 1700000000, Signer: addr}
+	sig, err := crypto.Sign(priv, audit.ReportPayload(r.NodeID, r.Index, r.LocalHash, r.Timestamp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Signature = hex.EncodeToString(sig)
+	return r
+}
+
+func TestRecordReportQuorumDivergence(t *testing.T) {
+	dir := t.TempDir()
+	s, closeDB := openTestStore(t, dir)
+	svc := NewAuditService(s)
+	if _, err := s.Append([]byte("entry")); err != nil {
+		t.Fatal(err)
+	}
+	local, err := svc.LocalHash(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := hex.EncodeToString(make([]byte, 32))
+	voters := []string{"n1", "n2", "n3"}
+
+	// 单个不一致的 follower 不足以判定 leader 分叉
+	if err := svc.RecordReport(signedReport(t, s, "n2", 1, other), voters); err != nil {
+		t.Fatalf("single report: %v", err)
+	}
+	if d := svc.Divergence(); d != nil {
+		t.Fatalf("unexpected divergence %+v", d)
+	}
+	// 签名者不是该节点登记身份的回报被拒绝：n3 的登记身份随后被换成另一把密钥
+	forged := signedReport(t, s, "n3", 1, other)
+	report := signedReport(t, s, "n3", 1, other)
+	if err := svc.RecordReport(forged, voters); !errors.Is(err, ErrInvalidReport) {
+		t.Fatalf("forged report: got %v, want ErrInvalidReport", err)
+	}
+	err = svc.RecordReport(report, voters)
+	if !errors.Is(err, ErrAuditDiverged) {
+		t.Fatalf("quorum report: got %v, want ErrAuditDiverged", err)
+	}
+	d := svc.Divergence()
+	if d == nil || d.Source != DivergenceQuorum || d.Expected != other || d.Local != local {
+		t.Fatalf("divergence = %+v", d)
+	}
+
+	// 分叉记录持久化，重启后依然生效，直到运维清除
+	closeDB()
+	s, closeDB = openTestStore(t, dir)
+	defer closeDB()
+	svc = NewAuditService(s)
+	if err := svc.CheckLatestCheckpoint(); err != nil {
+		t.Fatal(err)
+	}
+	if got := svc.Divergence(); got == nil || got.Index != 1 {
+		t.Fatalf("divergence after restart = %+v", got)
+	}
+	cleared, err := svc.ClearDivergence()
+	if err != nil || cleared == nil || cleared.Index != 1 {
+		t.Fatalf("ClearDivergence = %+v, %v", cleared, err)
+	}
+	if got := svc.Divergence(); got != nil {
+		t.Fatalf("divergence after clear = %+v", got)
+	}
+	// 清除水位线之前的回报不再触发分叉
+	for _, id := range []string{"n2", "n3"} {
+		if err := svc.RecordReport(signedReport(t, s, id, 1, other), voters); err != nil {
+			t.Fatalf("report after clear: %v", err)
+		}
+	}
+	if got := svc.Divergence(); got != nil {
+		t.Fatalf("divergence re-raised below watermark: %+v", got)
+	}
+}
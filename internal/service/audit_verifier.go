@@ -0,0 +1,142 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"distributed_ledger_go/internal/store"
+	"distributed_ledger_go/pkg/types"
+)
+
+// DefaultVerifyRate 为后台全量校验默认每秒校验的条目数。
+const DefaultVerifyRate = 5000
+
+var errVerifyStopped = errors.New("audit verification stopped")
+
+// VerifyProgress 为审计链全量校验的进度快照。
+type VerifyProgress struct {
+	Running    bool   `json:"running"`
+	Verified   uint64 `json:"verified"`
+	Target     uint64 `json:"target"`
+	StartedAt  int64  `json:"started_at,omitempty"`
+	FinishedAt int64  `json:"finished_at,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// AuditVerifier 负责启动时的增量校验与限速的后台全量校验。
+type AuditVerifier struct {
+	store *store.Store
+	rate  int
+
+	mu       sync.Mutex
+	progress VerifyProgress
+	quit     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewAuditVerifier 创建校验器，rate 为后台全量校验每秒校验的条目数，不大于 0 时使用默认值。
+func NewAuditVerifier(s *store.Store, rate int) *AuditVerifier {
+	if rate <= 0 {
+		rate = DefaultVerifyRate
+	}
+	return &AuditVerifier{store: s, rate: rate, quit: make(chan struct{})}
+}
+
+// 启动时只校验水位线之后新增的条目，返回本次校验的条目数。
+func (v *AuditVerifier) VerifyTail() (uint64, error) {
+	if v.store == nil {
+		return 0, nil
+	}
+	return v.store.VerifyTail()
+}
+
+// 在后台从第一个条目开始全量校验至当前链头；已在运行时返回 false。
+func (v *AuditVerifier) StartFull() bool {
+	if v.store == nil {
+		return false
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	select {
+	case <-v.quit:
+		return false
+	default:
+	}
+	if v.progress.Running {
+		return false
+	}
+	target, _, err := v.store.Head()
+	v.progress = VerifyProgress{Running: true, Target: target, StartedAt: time.Now().Unix()}
+	if err != nil {
+		v.finishLocked(err)
+		return true
+	}
+	v.wg.Add(1)
+	go v.runFull(target)
+	return true
+}
+
+// 返回当前全量校验进度。
+func (v *AuditVerifier) Progress() VerifyProgress {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.progress
+}
+
+// 停止后台校验并等待其退出。
+func (v *AuditVerifier) Close() {
+	v.mu.Lock()
+	select {
+	case <-v.quit:
+	default:
+		close(v.quit)
+	}
+	v.mu.Unlock()
+	v.wg.Wait()
+}
+
+func (v *AuditVerifier) runFull(target uint64) {
+	defer v.wg.Done()
+	const step = 100
+	start := time.Now()
+	var verified uint64
+	hash, err := v.store.VerifyRange(1, target, [32]byte{}, func(e *types.Entry) error {
+		verified++
+		if verified%step != 0 {
+			return nil
+		}
+		v.mu.Lock()
+		v.progress.Verified = verified
+		v.mu.Unlock()
+		// 按速率限流，避免全量校验与业务争抢磁盘
+		if wait := time.Duration(verified)*time.Second/time.Duration(v.rate) - time.Since(start); wait > 0 {
+			select {
+			case <-v.quit:
+				return errVerifyStopped
+			case <-time.After(wait):
+			}
+		}
+		select {
+		case <-v.quit:
+			return errVerifyStopped
+		default:
+		}
+		return nil
+	})
+	if err == nil && target > 0 {
+		err = v.store.SaveVerifiedMark(target, hash)
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.progress.Verified = verified
+	v.finishLocked(err)
+}
+
+func (v *AuditVerifier) finishLocked(err error) {
+	v.progress.Running = false
+	v.progress.FinishedAt = time.Now().Unix()
+	if err != nil {
+		v.progress.Error = err.Error()
+	}
+}
@@ -0,0 +1,11 @@
+package service
+
+import "errors"
+
+// 提交 Raft 命令时的可重试错误，由节点把 Raft 返回的错误转换而来。
+var (
+	// ErrNotLeader 表示本节点不是 leader 或在提交过程中失去了 leader 身份
+	ErrNotLeader = errors.New("not leader")
+	// ErrTimeout 表示命令未能在超时时间内进入 Raft 日志
+	ErrTimeout = errors.New("raft apply timeout")
+)
@@ -1,9 +1,15 @@
 package service
 
 import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
 	"distributed_ledger_go/internal/store"
 	"distributed_ledger_go/internal/txVerify"
-	"distributed_ledger_go/internal/types"
+	"distributed_ledger_go/pkg/audit"
+	"distributed_ledger_go/pkg/txhash"
+	"distributed_ledger_go/pkg/types"
 )
 
 // 负责交易的校验、审计以及状态落地。
@@ -21,19 +27,133 @@ func NewTransactionService(s *store.Store, v *txVerify.Validator, auditSvc *Audi
 	}
 }
 
-// 先通过 Validator 校验，再写审计，最后调用底层 Store。
-func (svc *TransactionService) Apply(tx types.Transaction) error {
+// 校验、写审计与状态落地在同一个 Badger 事务中完成；被拒绝的交易只记录失败回执，此时同时返回回执与拒绝原因。
+// 回执未能落盘时返回 nil 回执与存储错误。meta 为 leader 提议时确定的时间戳（Unix 秒）、任期以及交易所在的 Raft 日志索引，
+// 时间戳同时用于确定性地判断交易是否过期；payloadVersion 为审计载荷编码版本。
+func (svc *TransactionService) Apply(tx types.Transaction, meta store.AuditMeta, payloadVersion byte) (*types.Receipt, error) {
+	raftIndex := meta.RaftIndex
+	// 升级前写入 Raft 日志的交易没有 chain id，命令也没有载荷版本（0，即 JSON），签名按 txhash.LegacyTxHash 校验；
+	// 重放这些日志必须得到与当时相同的回执与审计条目，否则副本间分叉。新提议的命令总带有载荷版本，无法借此绕过 chain id 校验
+	legacy := payloadVersion == audit.PayloadVersionJSON && tx.ChainID == ""
+	hash := txhash.TxHash(tx)
+	if legacy && svc.validator != nil {
+		hash, _ = svc.validator.LegacySigningHash(tx)
+	}
+	receipt := &types.Receipt{
+		TxHash:    hex.EncodeToString(hash),
+		RaftIndex: raftIndex,
+	}
+
+	// 没有快照时节点重启会从头重放 Raft 日志，已执行过的条目直接返回原回执；
+	// 否则失败的交易可能在之后的状态下（如放宽的额度策略）被重新执行而与其它节点分叉
+	if raftIndex != 0 {
+		prev, err := svc.store.ReceiptAt(receipt.TxHash, raftIndex)
+		if err != nil {
+			return nil, err
+		}
+		if prev != nil {
+			if prev.Status == types.ReceiptStatusFailed {
+				return prev, errors.New(prev.Error)
+			}
+			return prev, nil
+		}
+	}
+
+	var payload []byte
+	if svc.audit != nil {
+		p, err := svc.audit.EncodeTransaction(tx, payloadVersion)
+		if err != nil {
+			return nil, err
+		}
+		payload = p
+	}
+
+	var check store.TxCheck
 	if svc.validator != nil {
-		if err := svc.validator.ValidateTransaction(tx); err != nil {
-			return err
+		check = func(view store.TxView) error {
+			if legacy {
+				return svc.validator.ValidateLegacyWith(view, tx, meta.Timestamp)
+			}
+			return svc.validator.ValidateWith(view, tx, meta.Timestamp)
 		}
 	}
 
+	if err := svc.store.ApplyTransaction(tx, check, payload, meta, receipt); err != nil {
+		if receipt.Status != types.ReceiptStatusFailed {
+			return nil, err
+		}
+		return receipt, err
+	}
+	return receipt, nil
+}
+
+// 读取当前额度策略，未设置时返回 nil。
+func (svc *TransactionService) Policy() (*types.Policy, error) {
+	return svc.store.GetPolicy()
+}
+
+// 读取账户的额度使用量。
+func (svc *TransactionService) Usage(address string) (*types.Usage, error) {
+	return svc.store.GetUsage(address)
+}
+
+// 读取全网发行量。
+func (svc *TransactionService) Supply() (*types.Supply, error) {
+	return svc.store.GetSupply()
+}
+
+// 校验所有账户余额之和等于发行量，返回发行量与余额之和；不一致时返回 store.ErrSupplyMismatch。
+func (svc *TransactionService) VerifySupply() (*types.Supply, uint64, error) {
+	return svc.store.VerifySupply()
+}
+
+// 为升级前的数据初始化发行量计数器。
+func (svc *TransactionService) EnsureSupply() error {
+	return svc.store.EnsureSupply()
+}
+
+// 按交易哈希（hex）查询回执。
+func (svc *TransactionService) GetReceipt(txHash string) (*types.Receipt, error) {
+	return svc.store.GetReceipt(txHash)
+}
+
+// 按地址分页查询流水。
+func (svc *TransactionService) ListHistory(address string, f store.HistoryFilter) ([]*types.HistoryRecord, uint64, error) {
+	return svc.store.ListHistory(address, f)
+}
+
+// 按交易类型分页查询全网流水。
+func (svc *TransactionService) ListHistoryByType(txType types.TxType, f store.HistoryFilter) ([]*types.HistoryRecord, uint64, error) {
+	return svc.store.ListHistoryByType(txType, f)
+}
+
+// 为升级前已有的审计条目补建流水索引，索引就绪后直接返回。
+func (svc *TransactionService) EnsureHistoryIndex() error {
+	ready, err := svc.store.HistoryIndexReady()
+	if err != nil || ready {
+		return err
+	}
+	var records []*types.HistoryRecord
 	if svc.audit != nil {
-		if _, err := svc.audit.AppendTransaction(tx); err != nil {
+		err := svc.audit.Iterate(1, func(e *types.Entry) error {
+			tx, err := svc.audit.DecodeTransaction(e.TxBytes)
+			if err != nil {
+				return fmt.Errorf("decode audit entry %d: %w", e.Index, err)
+			}
+			records = append(records, &types.HistoryRecord{
+				Index:     e.Index,
+				Type:      tx.Type,
+				Sender:    tx.Sender,
+				Receiver:  tx.Receiver,
+				Amount:    tx.Amount,
+				Nonce:     tx.Nonce,
+				Timestamp: e.Timestamp,
+			})
+			return nil
+		})
+		if err != nil {
 			return err
 		}
 	}
-
-	return svc.store.ApplyTransaction(tx)
+	return svc.store.BackfillHistory(records)
 }
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"distributed_ledger_go/internal/store"
+	"distributed_ledger_go/pkg/audit"
+	"distributed_ledger_go/pkg/types"
+)
+
+// 无快照重启时 Raft 日志从头重放：已执行过的索引直接返回原回执，不再改动状态；
+// 失败的交易即使此时能够成功也保持失败，否则各副本的状态会分叉
+func TestApplyReplayedRaftIndexIsIdempotent(t *testing.T) {
+	s, closeDB := openTestStore(t, t.TempDir())
+	defer closeDB()
+	svc := NewTransactionService(s, nil, NewAuditService(s))
+	for _, addr := range []string{"alice", "bob"} {
+		if _, err := s.RegisterWithRole(addr, types.RoleUser); err != nil {
+			t.Fatal(err)
+		}
+	}
+	meta := func(raftIndex uint64) store.AuditMeta {
+		return store.AuditMeta{Timestamp: 1000, Term: 1, RaftIndex: raftIndex}
+	}
+	transfer := types.Transaction{Type: types.TxTypeTransfer, Sender: "alice", Receiver: "bob", Amount: 10, Nonce: 1}
+	failed, err := svc.Apply(transfer, meta(1), audit.CurrentPayloadVersion)
+	if err == nil || failed.Status != types.ReceiptStatusFailed {
+		t.Fatalf("first transfer = %+v, %v; want failed", failed, err)
+	}
+	mint := types.Transaction{Type: types.TxTypeMint, Sender: "alice", Receiver: "alice", Amount: 100, Nonce: 1}
+	minted, err := svc.Apply(mint, meta(2), audit.CurrentPayloadVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 重放索引 1：余额已足够，但仍返回原失败回执
+	replayed, err := svc.Apply(transfer, meta(1), audit.CurrentPayloadVersion)
+	if err == nil || !reflect.DeepEqual(replayed, failed) {
+		t.Fatalf("replayed failure = %+v, %v; want %+v", replayed, err, failed)
+	}
+	// 重放索引 2：返回原成功回执，不重复铸币
+	replayed, err = svc.Apply(mint, meta(2), audit.CurrentPayloadVersion)
+	if err != nil || !reflect.DeepEqual(replayed, minted) {
+		t.Fatalf("replayed mint = %+v, %v; want %+v", replayed, err, minted)
+	}
+
+	acc, err := s.GetAccount("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acc.Balance != 100 || acc.Nonce != 1 {
+		t.Fatalf("alice = %+v, want balance 100 nonce 1", acc)
+	}
+	if head, _, err := s.Head(); err != nil || head != 1 {
+		t.Fatalf("audit head = %d, %v; want 1", head, err)
+	}
+}
@@ -1,7 +1,7 @@
 package store
 
 import (
-	"distributed_ledger_go/internal/types"
+	"distributed_ledger_go/pkg/types"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -49,7 +49,7 @@ func (s *Store) GetAccount(address string) (*types.Account, error) {
 	})
 
 	if errors.Is(err, badger.ErrKeyNotFound) {
-		return nil, fmt.Errorf("account not found: %s", address)
+		return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, address)
 	}
 	if err != nil {
 		return nil, err
@@ -79,11 +79,46 @@ func (s *Store) SetRole(address, role string) error {
 		return errors.New("nil store")
 	}
 	return s.db.Update(func(txn *badger.Txn) error {
-		acc, err := s.getAccountWithTxn(txn, address)
+		return s.setRoleWithTxn(txn, address, role)
+	})
+}
+
+// 由创世者签名的角色变更：在同一事务中确认签名者为创世者、校验并递增其 nonce，再设置角色
+func (s *Store) SetRoleSigned(signer string, nonce uint64, address, role string) error {
+	if s == nil || s.db == nil {
+		return errors.New("nil store")
+	}
+	return s.db.Update(func(txn *badger.Txn) error {
+		creator, _, err := s.creatorWithTxn(txn)
 		if err != nil {
 			return err
 		}
-		acc.Role = role
-		return s.saveAccountWithTxn(txn, acc)
+		if signer != creator {
+			return fmt.Errorf("%w: %s", ErrNotCreator, signer)
+		}
+		acc, err := s.getAccountWithTxn(txn, signer)
+		if err != nil {
+			return err
+		}
+		if nonce != acc.Nonce+1 {
+			return fmt.Errorf("%w: expected %d, got %d", ErrNonceMismatch, acc.Nonce+1, nonce)
+		}
+		acc.Nonce++
+		if err := s.saveAccountWithTxn(txn, acc); err != nil {
+			return err
+		}
+		return s.setRoleWithTxn(txn, address, role)
 	})
 }
+
+func (s *Store) setRoleWithTxn(txn *badger.Txn, address, role string) error {
+	acc, err := s.getAccountWithTxn(txn, address)
+	if err != nil {
+		return err
+	}
+	if err := s.checkRoleChangeWithTxn(txn, address, role); err != nil {
+		return err
+	}
+	acc.Role = role
+	return s.saveAccountWithTxn(txn, acc)
+}
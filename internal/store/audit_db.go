@@ -2,8 +2,8 @@ package store
 
 import (
 	"bytes"
-	"distributed_ledger_go/internal/types"
 	"distributed_ledger_go/pkg/audit"
+	"distributed_ledger_go/pkg/types"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -15,8 +15,19 @@ var (
 	keyLastIndex = []byte("audit:lastIndex")
 	keyLastHash  = []byte("audit:lastHash")
 	keyEntryPref = []byte("audit:entry:")
+	// 本节点已校验水位线：8 字节小端索引 + 该条目哈希，不进入快照
+	keyVerifiedMark = []byte("local:audit:verified")
+	// 最新条目的时间戳，保证携带元数据的条目时间单调不减
+	keyLastTime = []byte("audit:lastTime")
 )
 
+// AuditMeta 为审计条目的元数据：时间戳由 leader 提议时确定，任期与索引取自提交该命令的 Raft 日志条目
+type AuditMeta struct {
+	Timestamp int64
+	Term      uint64
+	RaftIndex uint64
+}
+
 // 将uint64 索引转成 8 字节小端
 func entryKey(index uint64) []byte {
 	var b [8]byte
@@ -34,41 +45,69 @@ func (s *Store) Append(txBytes []byte) (*types.Entry, error) {
 
 	var appended *types.Entry
 	err := s.db.Update(func(txn *badger.Txn) error {
-		lastIndex, lastHash, err := loadLast(txn)
+		e, err := s.appendWithTxn(txn, txCopy, AuditMeta{})
 		if err != nil {
 			return err
 		}
+		appended = e
+		return nil
+	})
+	return appended, err
+}
 
-		newIndex := lastIndex + 1
-		e := &types.Entry{
-			Index:    newIndex,
-			PrevHash: lastHash,
-			TxBytes:  txCopy,
-		}
-		e.EntryHash = audit.AuditHash(e.Index, e.PrevHash, e.TxBytes)
+// 在给定事务中追加审计条目，便于与账户变更一起原子提交
+func (s *Store) appendWithTxn(txn *badger.Txn, txBytes []byte, meta AuditMeta) (*types.Entry, error) {
+	lastIndex, lastHash, err := loadLast(txn)
+	if err != nil {
+		return nil, err
+	}
 
-		enc, err := audit.EncodeEntry(e)
+	newIndex := lastIndex + 1
+	e := &types.Entry{
+		Index:    newIndex,
+		Version:  audit.PayloadVersion(txBytes),
+		PrevHash: lastHash,
+		TxBytes:  txBytes,
+	}
+	if audit.HasEntryMeta(e.Version) {
+		// leader 时钟可能回拨或换主，取与上一条目时间的较大值，保证按时间二分查找有效
+		lastTime, err := loadLastTime(txn)
 		if err != nil {
-			return err
-		}
-
-		if err := txn.Set(entryKey(newIndex), enc); err != nil {
-			return err
+			return nil, err
 		}
-
-		var b8 [8]byte
-		binary.LittleEndian.PutUint64(b8[:], newIndex)
-		if err := txn.Set(keyLastIndex, b8[:]); err != nil {
-			return err
+		if meta.Timestamp < lastTime {
+			meta.Timestamp = lastTime
 		}
-		if err := txn.Set(keyLastHash, e.EntryHash[:]); err != nil {
-			return err
+		e.Timestamp = meta.Timestamp
+		e.Term = meta.Term
+		e.RaftIndex = meta.RaftIndex
+		if err := txn.Set(keyLastTime, binary.LittleEndian.AppendUint64(nil, uint64(e.Timestamp))); err != nil {
+			return nil, err
 		}
+	}
+	e.EntryHash = audit.EntryHash(e)
 
-		appended = e
-		return nil
-	})
-	return appended, err
+	enc, err := audit.EncodeEntry(e)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := txn.Set(entryKey(newIndex), enc); err != nil {
+		return nil, err
+	}
+	if err := s.appendMerkleWithTxn(txn, newIndex-1, e.EntryHash); err != nil {
+		return nil, err
+	}
+
+	var b8 [8]byte
+	binary.LittleEndian.PutUint64(b8[:], newIndex)
+	if err := txn.Set(keyLastIndex, b8[:]); err != nil {
+		return nil, err
+	}
+	if err := txn.Set(keyLastHash, e.EntryHash[:]); err != nil {
+		return nil, err
+	}
+	return e, nil
 }
 
 // 获取审计条目
@@ -79,6 +118,9 @@ func (s *Store) GetEntry(index uint64) (*types.Entry, error) {
 	var e *types.Entry
 	err := s.db.View(func(txn *badger.Txn) error {
 		it, err := txn.Get(entryKey(index))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return fmt.Errorf("%w: %d", ErrEntryNotFound, index)
+		}
 		if err != nil {
 			return err
 		}
@@ -96,53 +138,203 @@ func (s *Store) GetEntry(index uint64) (*types.Entry, error) {
 
 // 验证哈希链
 func (s *Store) VerifyChain() error {
+	lastIndex, _, err := s.Head()
+	if err != nil {
+		return err
+	}
+	_, err = s.VerifyRange(1, lastIndex, [32]byte{}, nil)
+	return err
+}
+
+// 校验 [from, to] 区间的哈希链，prevHash 为第 from-1 个条目的哈希（from 为 1 时为全零）。
+// 每批条目在独立的只读事务中读取，fn 在每个条目校验通过后调用，返回错误可中止校验。
+// 返回第 to 个条目的哈希。
+func (s *Store) VerifyRange(from, to uint64, prevHash [32]byte, fn func(*types.Entry) error) ([32]byte, error) {
+	const batch = 1000
 	if s == nil || s.db == nil {
-		return errors.New("nil audit store")
+		return prevHash, errors.New("nil audit store")
 	}
-	return s.db.View(func(txn *badger.Txn) error {
-		lastIndex, _, err := loadLast(txn)
-		if err != nil {
-			return err
+	for i := from; i <= to; {
+		end := i + batch - 1
+		if end > to {
+			end = to
 		}
-		if lastIndex == 0 {
+		var entries []*types.Entry
+		err := s.db.View(func(txn *badger.Txn) error {
+			for j := i; j <= end; j++ {
+				e, err := getEntryWithTxn(txn, j)
+				if err != nil {
+					return fmt.Errorf("decode audit entry %d: %w", j, err)
+				}
+				entries = append(entries, e)
+			}
 			return nil
+		})
+		if err != nil {
+			return prevHash, err
 		}
-
-		var prevHash [32]byte // genesis prevHash = 0
-		for i := uint64(1); i <= lastIndex; i++ {
-			item, err := txn.Get(entryKey(i))
-			if err != nil {
-				return fmt.Errorf("missing audit entry %d: %w", i, err)
+		for _, e := range entries {
+			if e.Index != i {
+				return prevHash, fmt.Errorf("audit entry index mismatch: want %d got %d", i, e.Index)
 			}
-			var e *types.Entry
-			err = item.Value(func(val []byte) error {
-				dec, derr := audit.DecodeEntry(val)
-				if derr != nil {
-					return derr
+			if e.PrevHash != prevHash {
+				return prevHash, fmt.Errorf("audit chain broken at %d: prevHash mismatch", i)
+			}
+			want := audit.EntryHash(e)
+			if !bytes.Equal(want[:], e.EntryHash[:]) {
+				return prevHash, fmt.Errorf("audit chain broken at %d: entryHash mismatch", i)
+			}
+			prevHash = e.EntryHash
+			if fn != nil {
+				if err := fn(e); err != nil {
+					return prevHash, err
 				}
-				e = dec
-				return nil
-			})
-			if err != nil {
-				return fmt.Errorf("decode audit entry %d: %w", i, err)
 			}
+			i++
+		}
+	}
+	return prevHash, nil
+}
 
-			if e.Index != i {
-				return fmt.Errorf("audit entry index mismatch: want %d got %d", i, e.Index)
-			}
-			if e.PrevHash != prevHash {
-				return fmt.Errorf("audit chain broken at %d: prevHash mismatch", i)
+// 只校验水位线之后新增的条目：先确认水位线处条目哈希未变，再从水位线继续校验到链头，
+// 最后确认链头哈希一致并推进水位线。返回本次校验的条目数。
+func (s *Store) VerifyTail() (uint64, error) {
+	mark, markHash, err := s.LoadVerifiedMark()
+	if err != nil {
+		return 0, err
+	}
+	lastIndex, lastHash, err := s.Head()
+	if err != nil {
+		return 0, err
+	}
+	if mark > lastIndex {
+		return 0, fmt.Errorf("audit chain truncated: verified up to %d but head is %d", mark, lastIndex)
+	}
+	if mark > 0 {
+		e, err := s.GetEntry(mark)
+		if err != nil {
+			return 0, fmt.Errorf("missing audit entry %d: %w", mark, err)
+		}
+		if e.EntryHash != markHash {
+			return 0, fmt.Errorf("audit chain broken at %d: entry differs from verified watermark", mark)
+		}
+	}
+	tail, err := s.VerifyRange(mark+1, lastIndex, markHash, nil)
+	if err != nil {
+		return 0, err
+	}
+	if tail != lastHash {
+		return 0, fmt.Errorf("audit chain head hash mismatch at %d", lastIndex)
+	}
+	if err := s.SaveVerifiedMark(lastIndex, lastHash); err != nil {
+		return 0, err
+	}
+	return lastIndex - mark, nil
+}
+
+// 读取已校验水位线：此索引及之前的条目均已通过哈希链校验
+func (s *Store) LoadVerifiedMark() (uint64, [32]byte, error) {
+	var index uint64
+	var hash [32]byte
+	if s == nil || s.db == nil {
+		return 0, hash, errors.New("nil audit store")
+	}
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(keyVerifiedMark)
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(v []byte) error {
+			if len(v) != 8+32 {
+				return errors.New("invalid verified watermark length")
 			}
+			index = binary.LittleEndian.Uint64(v[:8])
+			copy(hash[:], v[8:])
+			return nil
+		})
+	})
+	return index, hash, err
+}
 
-			want := audit.AuditHash(e.Index, e.PrevHash, e.TxBytes)
-			if !bytes.Equal(want[:], e.EntryHash[:]) {
-				return fmt.Errorf("audit chain broken at %d: entryHash mismatch", i)
+// 保存已校验水位线，只前进不后退
+func (s *Store) SaveVerifiedMark(index uint64, hash [32]byte) error {
+	if s == nil || s.db == nil {
+		return errors.New("nil audit store")
+	}
+	return s.db.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get(keyVerifiedMark)
+		if err == nil {
+			var cur uint64
+			if err := item.Value(func(v []byte) error {
+				if len(v) >= 8 {
+					cur = binary.LittleEndian.Uint64(v[:8])
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+			if cur > index {
+				return nil
 			}
+		} else if err != badger.ErrKeyNotFound {
+			return err
+		}
+		val := binary.LittleEndian.AppendUint64(make([]byte, 0, 8+32), index)
+		return txn.Set(keyVerifiedMark, append(val, hash[:]...))
+	})
+}
 
-			prevHash = e.EntryHash
+func loadLastTime(txn *badger.Txn) (int64, error) {
+	item, err := txn.Get(keyLastTime)
+	if err == badger.ErrKeyNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var ts int64
+	err = item.Value(func(v []byte) error {
+		if len(v) != 8 {
+			return errors.New("invalid lastTime length")
+		}
+		ts = int64(binary.LittleEndian.Uint64(v))
+		return nil
+	})
+	return ts, err
+}
+
+// 返回第一个时间戳不早于 ts 的条目索引；条目时间单调不减，未携带时间的旧条目视为 0。
+// 不存在时返回链头索引 + 1。
+func (s *Store) SearchEntryByTime(ts int64) (uint64, error) {
+	if s == nil || s.db == nil {
+		return 0, errors.New("nil audit store")
+	}
+	var found uint64
+	err := s.db.View(func(txn *badger.Txn) error {
+		lastIndex, _, err := loadLast(txn)
+		if err != nil {
+			return err
+		}
+		lo, hi := uint64(1), lastIndex+1
+		for lo < hi {
+			mid := lo + (hi-lo)/2
+			e, err := getEntryWithTxn(txn, mid)
+			if err != nil {
+				return err
+			}
+			if e.Timestamp < ts {
+				lo = mid + 1
+			} else {
+				hi = mid
+			}
 		}
+		found = lo
 		return nil
 	})
+	return found, err
 }
 
 // 读取lastIndex和lastHash
@@ -216,3 +408,123 @@ func (s *Store) ListEntries() ([]*types.Entry, error) {
 	})
 	return entries, err
 }
+
+// 读取审计链头部（最新索引与哈希）
+func (s *Store) Head() (uint64, [32]byte, error) {
+	if s == nil || s.db == nil {
+		return 0, [32]byte{}, errors.New("nil audit store")
+	}
+	var lastIndex uint64
+	var lastHash [32]byte
+	err := s.db.View(func(txn *badger.Txn) error {
+		var err error
+		lastIndex, lastHash, err = loadLast(txn)
+		return err
+	})
+	return lastIndex, lastHash, err
+}
+
+// 从 from 开始读取至多 limit 条审计条目
+func (s *Store) ListEntriesRange(from uint64, limit int) ([]*types.Entry, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("nil audit store")
+	}
+	if from == 0 {
+		from = 1
+	}
+	var entries []*types.Entry
+	err := s.db.View(func(txn *badger.Txn) error {
+		lastIndex, _, err := loadLast(txn)
+		if err != nil {
+			return err
+		}
+		for i := from; i <= lastIndex && len(entries) < limit; i++ {
+			e, err := getEntryWithTxn(txn, i)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, e)
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// 从 from 开始按索引顺序遍历审计条目，直到调用开始时的链头；
+// 每批使用独立的只读事务，避免长时间持有单个事务
+func (s *Store) IterateEntries(from uint64, fn func(*types.Entry) error) error {
+	const batch = 1000
+	lastIndex, _, err := s.Head()
+	if err != nil {
+		return err
+	}
+	if from == 0 {
+		from = 1
+	}
+	for from <= lastIndex {
+		n := lastIndex - from + 1
+		if n > batch {
+			n = batch
+		}
+		entries, err := s.ListEntriesRange(from, int(n))
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+		from += n
+	}
+	return nil
+}
+
+// 在给定事务中读取审计条目
+func getEntryWithTxn(txn *badger.Txn, index uint64) (*types.Entry, error) {
+	item, err := txn.Get(entryKey(index))
+	if err != nil {
+		return nil, fmt.Errorf("missing audit entry %d: %w", index, err)
+	}
+	var e *types.Entry
+	err = item.Value(func(val []byte) error {
+		dec, derr := audit.DecodeEntry(val)
+		if derr != nil {
+			return derr
+		}
+		e = dec
+		return nil
+	})
+	return e, err
+}
+
+// 生成审计日志从 oldSize 到 newSize 的一致性证明，同时返回两棵树的根
+func (s *Store) ConsistencyProof(oldSize, newSize uint64) ([][32]byte, [32]byte, [32]byte, error) {
+	var proof [][32]byte
+	var oldRoot, newRoot [32]byte
+	if s == nil || s.db == nil {
+		return nil, oldRoot, newRoot, errors.New("nil audit store")
+	}
+	if oldSize == 0 || oldSize > newSize {
+		return nil, oldRoot, newRoot, fmt.Errorf("%w: invalid tree sizes: old %d, new %d", ErrOutOfRange, oldSize, newSize)
+	}
+	err := s.db.View(func(txn *badger.Txn) error {
+		size, err := loadMerkleSize(txn)
+		if err != nil {
+			return err
+		}
+		if newSize > size {
+			return fmt.Errorf("%w: tree size %d exceeds audit log size %d", ErrOutOfRange, newSize, size)
+		}
+		read := merkleReader(txn)
+		if proof, err = audit.ConsistencyProof(read, oldSize, newSize); err != nil {
+			return err
+		}
+		if oldRoot, err = audit.SubtreeRoot(read, 0, oldSize); err != nil {
+			return err
+		}
+		newRoot, err = audit.SubtreeRoot(read, 0, newSize)
+		return err
+	})
+	return proof, oldRoot, newRoot, err
+}
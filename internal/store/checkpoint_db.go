@@ -0,0 +1,89 @@
+package store
+
+import (
+	"distributed_ledger_go/pkg/types"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+
+	badger "github.com/dgraph-io/badger/v3"
+)
+
+// 检查点按审计索引大端编码，便于顺序遍历
+var keyCheckpointPref = []byte("audit:checkpoint:")
+
+func checkpointKey(index uint64) []byte {
+	return binary.BigEndian.AppendUint64(append([]byte{}, keyCheckpointPref...), index)
+}
+
+// 保存检查点，同一审计索引只保留最先提交的检查点；返回是否写入
+func (s *Store) SaveCheckpoint(cp *types.Checkpoint) (bool, error) {
+	if s == nil || s.db == nil {
+		return false, errors.New("nil audit store")
+	}
+	val, err := json.Marshal(cp)
+	if err != nil {
+		return false, err
+	}
+	saved := false
+	err = s.db.Update(func(txn *badger.Txn) error {
+		key := checkpointKey(cp.Index)
+		if _, err := txn.Get(key); err == nil {
+			return nil
+		} else if err != badger.ErrKeyNotFound {
+			return err
+		}
+		saved = true
+		return txn.Set(key, val)
+	})
+	return saved, err
+}
+
+// 从审计索引 from 开始按顺序读取至多 limit 个检查点
+func (s *Store) ListCheckpoints(from uint64, limit int) ([]*types.Checkpoint, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("nil audit store")
+	}
+	var out []*types.Checkpoint
+	err := s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = keyCheckpointPref
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Seek(checkpointKey(from)); it.ValidForPrefix(keyCheckpointPref) && len(out) < limit; it.Next() {
+			var cp types.Checkpoint
+			if err := it.Item().Value(func(v []byte) error {
+				return json.Unmarshal(v, &cp)
+			}); err != nil {
+				return err
+			}
+			out = append(out, &cp)
+		}
+		return nil
+	})
+	return out, err
+}
+
+// 返回最新的检查点，不存在时返回 nil
+func (s *Store) LatestCheckpoint() (*types.Checkpoint, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("nil audit store")
+	}
+	var cp *types.Checkpoint
+	err := s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = keyCheckpointPref
+		opts.Reverse = true
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		it.Seek(checkpointKey(^uint64(0)))
+		if !it.ValidForPrefix(keyCheckpointPref) {
+			return nil
+		}
+		cp = &types.Checkpoint{}
+		return it.Item().Value(func(v []byte) error {
+			return json.Unmarshal(v, cp)
+		})
+	})
+	return cp, err
+}
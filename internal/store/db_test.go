@@ -0,0 +1,56 @@
+package store
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"distributed_ledger_go/pkg/audit"
+	"distributed_ledger_go/pkg/txhash"
+	"distributed_ledger_go/pkg/types"
+
+	badger "github.com/dgraph-io/badger/v3"
+)
+
+// 在临时目录打开 Badger，测试结束时关闭
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	db, err := badger.Open(badger.DefaultOptions(t.TempDir()).WithLogger(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return NewStore(db)
+}
+
+func mustRegister(t *testing.T, s *Store, address, role string) {
+	t.Helper()
+	if _, err := s.RegisterWithRole(address, role); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 不做业务校验直接执行交易，返回回执与执行结果
+func applyTx(s *Store, tx types.Transaction, raftIndex uint64, timestamp int64) (*types.Receipt, error) {
+	receipt := &types.Receipt{TxHash: hex.EncodeToString(txhash.TxHash(tx)), RaftIndex: raftIndex}
+	meta := AuditMeta{Timestamp: timestamp, Term: 1, RaftIndex: raftIndex}
+	err := s.ApplyTransaction(tx, nil, audit.EncodeTransaction(tx), meta, receipt)
+	return receipt, err
+}
+
+func mustApply(t *testing.T, s *Store, tx types.Transaction, raftIndex uint64, timestamp int64) *types.Receipt {
+	t.Helper()
+	r, err := applyTx(s, tx, raftIndex, timestamp)
+	if err != nil {
+		t.Fatalf("apply %+v: %v", tx, err)
+	}
+	return r
+}
+
+func mustAccount(t *testing.T, s *Store, address string) *types.Account {
+	t.Helper()
+	acc, err := s.GetAccount(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return acc
+}
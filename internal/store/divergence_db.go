@@ -0,0 +1,87 @@
+package store
+
+import (
+	"distributed_ledger_go/pkg/types"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+
+	badger "github.com/dgraph-io/badger/v3"
+)
+
+// 本节点审计链分叉记录与运维清除水位线，属于副本本地状态，不经 Raft 复制也不进入快照
+var (
+	keyDivergence        = []byte("local:audit:divergence")
+	keyDivergenceCleared = []byte("local:audit:divergence:cleared")
+)
+
+// 保存分叉记录，已有记录时保留最先发现的位置；返回是否写入
+func (s *Store) SaveDivergence(d *types.AuditDivergence) (bool, error) {
+	if s == nil || s.db == nil {
+		return false, errors.New("nil audit store")
+	}
+	val, err := json.Marshal(d)
+	if err != nil {
+		return false, err
+	}
+	saved := false
+	err = s.db.Update(func(txn *badger.Txn) error {
+		if _, err := txn.Get(keyDivergence); err == nil {
+			return nil
+		} else if err != badger.ErrKeyNotFound {
+			return err
+		}
+		saved = true
+		return txn.Set(keyDivergence, val)
+	})
+	return saved, err
+}
+
+// 读取分叉记录与清除水位线，没有分叉记录时返回 nil
+func (s *Store) LoadDivergence() (*types.AuditDivergence, uint64, error) {
+	if s == nil || s.db == nil {
+		return nil, 0, errors.New("nil audit store")
+	}
+	var d *types.AuditDivergence
+	var cleared uint64
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(keyDivergence)
+		if err == nil {
+			d = &types.AuditDivergence{}
+			if err := item.Value(func(v []byte) error {
+				return json.Unmarshal(v, d)
+			}); err != nil {
+				return err
+			}
+		} else if err != badger.ErrKeyNotFound {
+			return err
+		}
+		item, err = txn.Get(keyDivergenceCleared)
+		if err == badger.ErrKeyNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		return item.Value(func(v []byte) error {
+			if len(v) != 8 {
+				return errors.New("invalid divergence watermark")
+			}
+			cleared = binary.BigEndian.Uint64(v)
+			return nil
+		})
+	})
+	return d, cleared, err
+}
+
+// 删除分叉记录，并把清除水位线推进到 index：此后只比对 index 之后的检查点与回报
+func (s *Store) ClearDivergence(index uint64) error {
+	if s == nil || s.db == nil {
+		return errors.New("nil audit store")
+	}
+	return s.db.Update(func(txn *badger.Txn) error {
+		if err := txn.Delete(keyDivergence); err != nil {
+			return err
+		}
+		return txn.Set(keyDivergenceCleared, binary.BigEndian.AppendUint64(nil, index))
+	})
+}
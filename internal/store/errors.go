@@ -0,0 +1,21 @@
+package store
+
+import "errors"
+
+// 交易执行中的业务错误，调用方可用 errors.Is 判断类别，错误信息中会附带具体细节。
+var (
+	ErrAccountNotFound     = errors.New("account not found")
+	ErrAccountExists       = errors.New("account already exists")
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrNonceMismatch       = errors.New("nonce mismatch")
+	ErrFrozen              = errors.New("account is frozen")
+	ErrUnknownTxType       = errors.New("unknown transaction type")
+	ErrNotCreator          = errors.New("only the creator can change roles")
+)
+
+// 查询类错误：所查对象不存在或请求的范围超出当前数据。
+var (
+	ErrReceiptNotFound = errors.New("receipt not found")
+	ErrEntryNotFound   = errors.New("audit entry not found")
+	ErrOutOfRange      = errors.New("out of range")
+)
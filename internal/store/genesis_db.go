@@ -0,0 +1,208 @@
+package store
+
+import (
+	"distributed_ledger_go/pkg/types"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/bits"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+// 创世者地址，随 Raft 复制写入，一经确定不可更改
+var keyCreator = []byte("genesis:creator")
+
+// ErrCreatorFixed 表示试图授予或撤销创世者角色
+var ErrCreatorFixed = errors.New("creator role is fixed at genesis")
+
+// 读取创世者地址，stored 表示是否已写入 genesis:creator；旧数据没有该键时扫描角色为 CREATOR 的账户
+func (s *Store) creatorWithTxn(txn *badger.Txn) (string, bool, error) {
+	item, err := txn.Get(keyCreator)
+	if err == nil {
+		v, err := item.ValueCopy(nil)
+		return string(v), true, err
+	}
+	if err != badger.ErrKeyNotFound {
+		return "", false, err
+	}
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = []byte(AccPrefix)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+	for it.Rewind(); it.Valid(); it.Next() {
+		var acc types.Account
+		if err := it.Item().Value(func(v []byte) error {
+			return json.Unmarshal(v, &acc)
+		}); err != nil {
+			return "", false, err
+		}
+		if acc.Role == types.RoleCreator {
+			return acc.Address, false, nil
+		}
+	}
+	return "", false, nil
+}
+
+// 查询创世者地址，尚未产生创世者时返回空字符串
+func (s *Store) Creator() (string, error) {
+	if s == nil || s.db == nil {
+		return "", errors.New("nil store")
+	}
+	var creator string
+	err := s.db.View(func(txn *badger.Txn) error {
+		c, _, err := s.creatorWithTxn(txn)
+		creator = c
+		return err
+	})
+	return creator, err
+}
+
+// 注册账户并在同一事务中确定角色，返回最终角色。旧日志中 role 可能为空（尚无创世者时成为创世者）
+// 或为 CREATOR（已有其它创世者时降为普通用户）。
+func (s *Store) RegisterWithRole(address, role string) (string, error) {
+	if s == nil || s.db == nil {
+		return "", errors.New("nil store")
+	}
+	err := s.db.Update(func(txn *badger.Txn) error {
+		key := []byte(AccPrefix + address)
+		if _, err := txn.Get(key); err == nil {
+			return ErrAccountExists
+		} else if err != badger.ErrKeyNotFound {
+			return err
+		}
+		creator, stored, err := s.creatorWithTxn(txn)
+		if err != nil {
+			return err
+		}
+		switch {
+		case role == "" && creator == "":
+			role = types.RoleCreator
+		case role == "" || role == types.RoleCreator && creator != "":
+			role = types.RoleUser
+		}
+		if role == types.RoleCreator {
+			creator = address
+			stored = false
+		}
+		if creator != "" && !stored {
+			if err := txn.Set(keyCreator, []byte(creator)); err != nil {
+				return err
+			}
+		}
+		acc := &types.Account{Address: address}
+		if role != types.RoleUser {
+			acc.Role = role
+		}
+		return s.saveAccountWithTxn(txn, acc)
+	})
+	return role, err
+}
+
+// 校验角色变更不涉及创世者：不能把其它账户设为创世者，也不能改变创世者的角色
+func (s *Store) checkRoleChangeWithTxn(txn *badger.Txn, address, role string) error {
+	creator, _, err := s.creatorWithTxn(txn)
+	if err != nil {
+		return err
+	}
+	if address == creator && role != types.RoleCreator {
+		return fmt.Errorf("%w: cannot change role of creator %s", ErrCreatorFixed, address)
+	}
+	if role == types.RoleCreator && address != creator {
+		return fmt.Errorf("%w: creator is %s", ErrCreatorFixed, creator)
+	}
+	return nil
+}
+
+// 已写入的创世配置哈希
+var keyGenesisHash = []byte("genesis:hash")
+
+// ErrGenesisMismatch 表示创世配置与集群已写入的不一致
+var ErrGenesisMismatch = errors.New("genesis hash mismatch")
+
+// 读取已写入的创世哈希，未使用创世配置时返回空字符串
+func (s *Store) GenesisHash() (string, error) {
+	if s == nil || s.db == nil {
+		return "", errors.New("nil store")
+	}
+	var hash string
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(keyGenesisHash)
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		v, err := item.ValueCopy(nil)
+		hash = string(v)
+		return err
+	})
+	return hash, err
+}
+
+// 在同一事务中写入创世状态：创世者、管理员、初始余额与创世哈希。
+// 相同哈希重复写入视为成功；已写入其它创世配置或已有创世者时拒绝。
+func (s *Store) ApplyGenesis(g *types.Genesis, hash string) error {
+	if s == nil || s.db == nil {
+		return errors.New("nil store")
+	}
+	return s.db.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get(keyGenesisHash)
+		if err == nil {
+			v, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			if string(v) != hash {
+				return fmt.Errorf("%w: cluster %s, got %s", ErrGenesisMismatch, v, hash)
+			}
+			return nil
+		} else if err != badger.ErrKeyNotFound {
+			return err
+		}
+		creator, _, err := s.creatorWithTxn(txn)
+		if err != nil {
+			return err
+		}
+		if creator != "" {
+			return fmt.Errorf("%w: ledger already has creator %s", ErrGenesisMismatch, creator)
+		}
+
+		accounts := map[string]*types.Account{}
+		account := func(address string) *types.Account {
+			acc, ok := accounts[address]
+			if !ok {
+				acc = &types.Account{Address: address}
+				accounts[address] = acc
+			}
+			return acc
+		}
+		account(g.Creator).Role = types.RoleCreator
+		for _, admin := range g.Admins {
+			account(admin).Role = types.RoleAdmin
+		}
+		sup := &types.Supply{}
+		for address, amount := range g.Balances {
+			account(address).Balance = amount
+			total, carry := bits.Add64(sup.TotalSupply, amount, 0)
+			if carry != 0 {
+				return ErrSupplyOverflow
+			}
+			sup.TotalSupply = total
+		}
+		sup.Genesis = sup.TotalSupply
+		if err := s.saveSupplyWithTxn(txn, sup); err != nil {
+			return err
+		}
+		for _, acc := range accounts {
+			if err := s.saveAccountWithTxn(txn, acc); err != nil {
+				return err
+			}
+		}
+		if err := txn.Set(keyCreator, []byte(g.Creator)); err != nil {
+			return err
+		}
+		return txn.Set(keyGenesisHash, []byte(hash))
+	})
+}
@@ -0,0 +1,208 @@
+package store
+
+import (
+	"distributed_ledger_go/pkg/types"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+const (
+	HistoryPrefix = "hist:"
+	// HistoryTypePrefix 为按交易类型的全网流水索引，供管理员与创世者的全局查询使用
+	HistoryTypePrefix = "histtype:"
+)
+
+// 索引就绪标记；v2 起同时包含按类型的索引，旧标记存在时会重新补建
+var keyHistoryReady = []byte("histmeta:ready:v2")
+
+// HistoryFilter 描述账户流水查询条件，零值字段表示不过滤。
+type HistoryFilter struct {
+	Type         *types.TxType
+	Counterparty string
+	FromIndex    uint64
+	ToIndex      uint64
+	// FromTime、ToTime 为交易时间范围（Unix 秒，闭区间）；设置后不返回没有时间戳的旧记录
+	FromTime int64
+	ToTime   int64
+	// Cursor 为上一页最后一条记录的审计索引，本页从其之后开始
+	Cursor uint64
+	Limit  int
+	// Match 为调用方附加的过滤条件，在计入 Limit 之前应用；为 nil 时不过滤
+	Match func(*types.HistoryRecord) (bool, error)
+}
+
+// 地址流水索引 key：hist:<address>:<审计索引大端序>，保证同一地址按索引有序
+func historyKey(address string, index uint64) []byte {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], index)
+	return append([]byte(HistoryPrefix+address+":"), b[:]...)
+}
+
+// 类型流水索引 key：histtype:<类型>:<审计索引大端序>
+func historyTypeKey(txType types.TxType, index uint64) []byte {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], index)
+	return append(historyTypePrefix(txType), b[:]...)
+}
+
+func historyTypePrefix(txType types.TxType) []byte {
+	return []byte(fmt.Sprintf("%s%d:", HistoryTypePrefix, txType))
+}
+
+// 在给定事务中为发送者、接收者与交易类型写入流水索引
+func (s *Store) indexHistoryWithTxn(txn *badger.Txn, rec *types.HistoryRecord) error {
+	val, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return setHistory(txn.Set, rec, val)
+}
+
+// 写入一条流水记录的全部索引项，set 为事务或批量写入的 Set
+func setHistory(set func(key, val []byte) error, rec *types.HistoryRecord, val []byte) error {
+	if err := set(historyTypeKey(rec.Type, rec.Index), val); err != nil {
+		return err
+	}
+	if err := set(historyKey(rec.Sender, rec.Index), val); err != nil {
+		return err
+	}
+	if rec.Receiver == rec.Sender {
+		return nil
+	}
+	return set(historyKey(rec.Receiver, rec.Index), val)
+}
+
+// 按地址分页读取流水，返回本页记录与下一页游标（0 表示没有更多数据）
+func (s *Store) ListHistory(address string, f HistoryFilter) ([]*types.HistoryRecord, uint64, error) {
+	return s.scanHistory([]byte(HistoryPrefix+address+":"), address, f)
+}
+
+// 按交易类型分页读取全网流水，忽略 f.Type 与 f.Counterparty
+func (s *Store) ListHistoryByType(txType types.TxType, f HistoryFilter) ([]*types.HistoryRecord, uint64, error) {
+	f.Type, f.Counterparty = nil, ""
+	return s.scanHistory(historyTypePrefix(txType), "", f)
+}
+
+// 在 prefix 下按审计索引顺序分页扫描流水；address 为空时不按对手方过滤
+func (s *Store) scanHistory(prefix []byte, address string, f HistoryFilter) ([]*types.HistoryRecord, uint64, error) {
+	if s == nil || s.db == nil {
+		return nil, 0, errors.New("nil store")
+	}
+	if f.Limit <= 0 {
+		return nil, 0, errors.New("invalid limit")
+	}
+	start := f.FromIndex
+	if f.Cursor+1 > start {
+		start = f.Cursor + 1
+	}
+	var seek [8]byte
+	binary.BigEndian.PutUint64(seek[:], start)
+
+	var records []*types.HistoryRecord
+	var next uint64
+	err := s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(append(append([]byte(nil), prefix...), seek[:]...)); it.ValidForPrefix(prefix); it.Next() {
+			key := it.Item().Key()
+			idx := binary.BigEndian.Uint64(key[len(prefix):])
+			if f.ToIndex != 0 && idx > f.ToIndex {
+				break
+			}
+			var rec types.HistoryRecord
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &rec)
+			}); err != nil {
+				return err
+			}
+			if f.FromTime != 0 || f.ToTime != 0 {
+				if rec.Timestamp == 0 || rec.Timestamp < f.FromTime {
+					continue
+				}
+				// 条目时间随索引单调不减，之后的记录都已超出范围
+				if f.ToTime != 0 && rec.Timestamp > f.ToTime {
+					break
+				}
+			}
+			if f.Type != nil && rec.Type != *f.Type {
+				continue
+			}
+			if f.Counterparty != "" && address != "" && counterparty(address, &rec) != f.Counterparty {
+				continue
+			}
+			if f.Match != nil {
+				ok, err := f.Match(&rec)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					continue
+				}
+			}
+			if len(records) == f.Limit {
+				next = records[len(records)-1].Index
+				return nil
+			}
+			records = append(records, &rec)
+		}
+		return nil
+	})
+	return records, next, err
+}
+
+// 历史数据是否已建立流水索引
+func (s *Store) HistoryIndexReady() (bool, error) {
+	if s == nil || s.db == nil {
+		return false, errors.New("nil store")
+	}
+	ready := false
+	err := s.db.View(func(txn *badger.Txn) error {
+		_, err := txn.Get(keyHistoryReady)
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		ready = true
+		return nil
+	})
+	return ready, err
+}
+
+// 批量写入历史流水索引并标记索引已就绪，用于升级前已存在的审计数据
+func (s *Store) BackfillHistory(records []*types.HistoryRecord) error {
+	if s == nil || s.db == nil {
+		return errors.New("nil store")
+	}
+	wb := s.db.NewWriteBatch()
+	defer wb.Cancel()
+	for _, rec := range records {
+		val, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		if err := setHistory(wb.Set, rec, val); err != nil {
+			return err
+		}
+	}
+	if err := wb.Set(keyHistoryReady, []byte{1}); err != nil {
+		return err
+	}
+	return wb.Flush()
+}
+
+// 返回记录中相对 address 的对手方地址
+func counterparty(address string, rec *types.HistoryRecord) string {
+	if rec.Sender == address {
+		return rec.Receiver
+	}
+	return rec.Sender
+}
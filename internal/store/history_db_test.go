@@ -0,0 +1,42 @@
+package store
+
+import (
+	"testing"
+
+	"distributed_ledger_go/pkg/types"
+)
+
+// Match 在计入 Limit 之前过滤，被过滤掉的记录不会让一页变短
+func TestListHistoryByTypeMatchBeforeLimit(t *testing.T) {
+	s := newTestStore(t)
+	mustRegister(t, s, "creator", types.RoleCreator)
+	mustRegister(t, s, "admin", types.RoleAdmin)
+	mustRegister(t, s, "user", types.RoleUser)
+	receivers := []string{"user", "admin", "user", "user", "admin", "admin"}
+	for i, to := range receivers {
+		mustApply(t, s, types.Transaction{Type: types.TxTypeMint, Sender: "creator", Receiver: to, Amount: 1, Nonce: uint64(i + 1)}, uint64(i+1), 1000)
+	}
+	toAdmin := func(rec *types.HistoryRecord) (bool, error) { return rec.Receiver == "admin", nil }
+
+	var got []uint64
+	f := HistoryFilter{Limit: 2, Match: toAdmin}
+	for {
+		records, next, err := s.ListHistoryByType(types.TxTypeMint, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if next != 0 && len(records) != f.Limit {
+			t.Fatalf("short page %d with next cursor %d", len(records), next)
+		}
+		for _, rec := range records {
+			got = append(got, rec.Index)
+		}
+		if next == 0 {
+			break
+		}
+		f.Cursor = next
+	}
+	if len(got) != 3 || got[0] != 2 || got[1] != 5 || got[2] != 6 {
+		t.Fatalf("indexes = %v, want [2 5 6]", got)
+	}
+}
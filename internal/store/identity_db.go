@@ -0,0 +1,71 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+
+	badger "github.com/dgraph-io/badger/v3"
+)
+
+// 节点身份登记表：节点 ID -> 节点身份地址（hex 公钥），经 Raft 复制，检查点只接受登记过的签名者
+var keyIdentityPref = []byte("identity:")
+
+// ErrUnknownIdentity 表示签名者不是已登记的集群节点身份
+var ErrUnknownIdentity = errors.New("signer is not a registered node identity")
+
+func identityKey(nodeID string) []byte {
+	return append(append([]byte{}, keyIdentityPref...), nodeID...)
+}
+
+// 登记节点的身份地址，identity 为空时删除该节点
+func (s *Store) SetIdentity(nodeID, identity string) error {
+	if s == nil || s.db == nil {
+		return errors.New("nil store")
+	}
+	return s.db.Update(func(txn *badger.Txn) error {
+		if identity == "" {
+			return txn.Delete(identityKey(nodeID))
+		}
+		return txn.Set(identityKey(nodeID), []byte(identity))
+	})
+}
+
+// 列出全部已登记的节点身份
+func (s *Store) ListIdentities() (map[string]string, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("nil store")
+	}
+	out := map[string]string{}
+	err := s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = keyIdentityPref
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			v, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			out[string(it.Item().Key()[len(keyIdentityPref):])] = string(v)
+		}
+		return nil
+	})
+	return out, err
+}
+
+// 确认 signer 是已登记的节点身份，否则返回 ErrUnknownIdentity；尚无登记表（旧集群）时不做限制
+func (s *Store) CheckIdentity(signer string) error {
+	identities, err := s.ListIdentities()
+	if err != nil {
+		return err
+	}
+	if len(identities) == 0 {
+		return nil
+	}
+	for _, id := range identities {
+		if id == signer {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrUnknownIdentity, signer)
+}
@@ -0,0 +1,181 @@
+package store
+
+import (
+	"distributed_ledger_go/pkg/audit"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	badger "github.com/dgraph-io/badger/v3"
+)
+
+var (
+	keyMerkleSize    = []byte("merkle:size")
+	keyMerkleNodePre = []byte("merkle:node:")
+)
+
+// Merkle 节点 key：merkle:node:<level 1 字节><index 8 字节大端序>
+func merkleNodeKey(level uint8, index uint64) []byte {
+	k := append([]byte{}, keyMerkleNodePre...)
+	k = append(k, level)
+	return binary.BigEndian.AppendUint64(k, index)
+}
+
+// 在给定事务中向 Merkle 累加器追加叶子，并补齐由此完成的完美子树节点
+func (s *Store) appendMerkleWithTxn(txn *badger.Txn, leaf uint64, entryHash [32]byte) error {
+	size, err := loadMerkleSize(txn)
+	if err != nil {
+		return err
+	}
+	if size != leaf {
+		return fmt.Errorf("merkle accumulator out of sync: size %d, appending leaf %d", size, leaf)
+	}
+
+	node := audit.MerkleLeafHash(entryHash)
+	level, index := uint8(0), leaf
+	// Badger 在提交前持有 value 的引用，因此每次写入都使用独立的切片
+	if err := txn.Set(merkleNodeKey(level, index), append([]byte(nil), node[:]...)); err != nil {
+		return err
+	}
+	for index&1 == 1 {
+		left, err := readMerkleNode(txn, level, index-1)
+		if err != nil {
+			return err
+		}
+		node = audit.MerkleNodeHash(left, node)
+		level++
+		index >>= 1
+		if err := txn.Set(merkleNodeKey(level, index), append([]byte(nil), node[:]...)); err != nil {
+			return err
+		}
+	}
+
+	var b8 [8]byte
+	binary.LittleEndian.PutUint64(b8[:], leaf+1)
+	return txn.Set(keyMerkleSize, b8[:])
+}
+
+// 为升级前已存在的审计条目补建 Merkle 累加器
+func (s *Store) EnsureMerkle() error {
+	if s == nil || s.db == nil {
+		return errors.New("nil audit store")
+	}
+	for {
+		done := false
+		err := s.db.Update(func(txn *badger.Txn) error {
+			lastIndex, _, err := loadLast(txn)
+			if err != nil {
+				return err
+			}
+			size, err := loadMerkleSize(txn)
+			if err != nil {
+				return err
+			}
+			// 分批补建，避免单个事务过大
+			for n := 0; size < lastIndex && n < 1000; n++ {
+				e, err := getEntryWithTxn(txn, size+1)
+				if err != nil {
+					return err
+				}
+				if err := s.appendMerkleWithTxn(txn, size, e.EntryHash); err != nil {
+					return err
+				}
+				size++
+			}
+			done = size >= lastIndex
+			return nil
+		})
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+// 计算大小为 treeSize 的审计树根
+func (s *Store) MerkleRoot(treeSize uint64) ([32]byte, error) {
+	if s == nil || s.db == nil {
+		return [32]byte{}, errors.New("nil audit store")
+	}
+	var root [32]byte
+	err := s.db.View(func(txn *badger.Txn) error {
+		size, err := loadMerkleSize(txn)
+		if err != nil {
+			return err
+		}
+		if treeSize > size {
+			return fmt.Errorf("%w: tree size %d exceeds audit log size %d", ErrOutOfRange, treeSize, size)
+		}
+		root, err = audit.SubtreeRoot(merkleReader(txn), 0, treeSize)
+		return err
+	})
+	return root, err
+}
+
+// 生成审计条目 index（从 1 开始）在大小为 treeSize 的审计树中的包含性证明及对应树根
+func (s *Store) InclusionProof(index, treeSize uint64) ([][32]byte, [32]byte, error) {
+	if s == nil || s.db == nil {
+		return nil, [32]byte{}, errors.New("nil audit store")
+	}
+	if index == 0 || index > treeSize {
+		return nil, [32]byte{}, fmt.Errorf("%w: index %d for tree size %d", ErrOutOfRange, index, treeSize)
+	}
+	var proof [][32]byte
+	var root [32]byte
+	err := s.db.View(func(txn *badger.Txn) error {
+		size, err := loadMerkleSize(txn)
+		if err != nil {
+			return err
+		}
+		if treeSize > size {
+			return fmt.Errorf("%w: tree size %d exceeds audit log size %d", ErrOutOfRange, treeSize, size)
+		}
+		read := merkleReader(txn)
+		if proof, err = audit.InclusionProof(read, index-1, treeSize); err != nil {
+			return err
+		}
+		root, err = audit.SubtreeRoot(read, 0, treeSize)
+		return err
+	})
+	return proof, root, err
+}
+
+func merkleReader(txn *badger.Txn) audit.NodeReader {
+	return func(level uint8, index uint64) ([32]byte, error) {
+		return readMerkleNode(txn, level, index)
+	}
+}
+
+func readMerkleNode(txn *badger.Txn, level uint8, index uint64) ([32]byte, error) {
+	var h [32]byte
+	item, err := txn.Get(merkleNodeKey(level, index))
+	if err != nil {
+		return h, fmt.Errorf("missing merkle node level=%d index=%d: %w", level, index, err)
+	}
+	err = item.Value(func(v []byte) error {
+		if len(v) != 32 {
+			return errors.New("invalid merkle node length")
+		}
+		copy(h[:], v)
+		return nil
+	})
+	return h, err
+}
+
+func loadMerkleSize(txn *badger.Txn) (uint64, error) {
+	item, err := txn.Get(keyMerkleSize)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var size uint64
+	err = item.Value(func(v []byte) error {
+		if len(v) != 8 {
+			return errors.New("invalid merkle size length")
+		}
+		size = binary.LittleEndian.Uint64(v)
+		return nil
+	})
+	return size, err
+}
@@ -0,0 +1,71 @@
+package store
+
+import (
+	"errors"
+
+	badger "github.com/dgraph-io/badger/v3"
+)
+
+// 集群成员表：Raft 地址 -> 对外 HTTP 地址，经 Raft 复制，供 follower 把请求转发给 leader
+var keyPeerPref = []byte("peer:")
+
+func peerKey(raftAddr string) []byte {
+	return append(append([]byte{}, keyPeerPref...), raftAddr...)
+}
+
+// 记录节点的 HTTP 地址，httpAddr 为空时删除该节点
+func (s *Store) SetPeer(raftAddr, httpAddr string) error {
+	if s == nil || s.db == nil {
+		return errors.New("nil store")
+	}
+	return s.db.Update(func(txn *badger.Txn) error {
+		if httpAddr == "" {
+			return txn.Delete(peerKey(raftAddr))
+		}
+		return txn.Set(peerKey(raftAddr), []byte(httpAddr))
+	})
+}
+
+// 查询节点的 HTTP 地址，未登记时返回空字符串
+func (s *Store) GetPeer(raftAddr string) (string, error) {
+	if s == nil || s.db == nil {
+		return "", errors.New("nil store")
+	}
+	var httpAddr string
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(peerKey(raftAddr))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		v, err := item.ValueCopy(nil)
+		httpAddr = string(v)
+		return err
+	})
+	return httpAddr, err
+}
+
+// 列出全部已登记节点
+func (s *Store) ListPeers() (map[string]string, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("nil store")
+	}
+	out := map[string]string{}
+	err := s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = keyPeerPref
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			v, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			out[string(it.Item().Key()[len(keyPeerPref):])] = string(v)
+		}
+		return nil
+	})
+	return out, err
+}
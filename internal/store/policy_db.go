@@ -0,0 +1,154 @@
+package store
+
+import (
+	"bytes"
+	"distributed_ledger_go/pkg/crypto"
+	"distributed_ledger_go/pkg/types"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/bits"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+var (
+	// 当前额度策略，由 TxTypeSetPolicy 交易写入
+	keyPolicy = []byte("ledger:policy")
+	// 账户额度使用量，键为 usage:<address>
+	keyUsagePref = []byte("usage:")
+)
+
+// ErrInvalidPolicy 表示额度策略格式或内容不合法
+var ErrInvalidPolicy = errors.New("invalid policy")
+
+// 解析并校验 JSON 编码的额度策略：拒绝未知字段、未知角色与非法账户地址
+func ParsePolicy(data []byte) (*types.Policy, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var p types.Policy
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPolicy, err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("%w: trailing data", ErrInvalidPolicy)
+	}
+	for role := range p.Roles {
+		switch role {
+		case types.RoleCreator, types.RoleAdmin, types.RoleUser:
+		default:
+			return nil, fmt.Errorf("%w: unknown role %q", ErrInvalidPolicy, role)
+		}
+	}
+	for addr := range p.Accounts {
+		if _, err := crypto.HexToPublicKey(addr); err != nil {
+			return nil, fmt.Errorf("%w: invalid account %q", ErrInvalidPolicy, addr)
+		}
+	}
+	return &p, nil
+}
+
+// 读取额度策略，未设置时返回 nil
+func (s *Store) policyWithTxn(txn *badger.Txn) (*types.Policy, error) {
+	item, err := txn.Get(keyPolicy)
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var p types.Policy
+	err = item.Value(func(v []byte) error {
+		return json.Unmarshal(v, &p)
+	})
+	return &p, err
+}
+
+// 保存额度策略；json.Marshal 对 map 键排序，各节点写入的字节一致
+func (s *Store) savePolicyWithTxn(txn *badger.Txn, p *types.Policy) error {
+	val, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return txn.Set(keyPolicy, val)
+}
+
+// 读取账户额度使用量，尚无记录时返回零值
+func (s *Store) usageWithTxn(txn *badger.Txn, address string) (*types.Usage, error) {
+	item, err := txn.Get(append(append([]byte(nil), keyUsagePref...), address...))
+	if err == badger.ErrKeyNotFound {
+		return &types.Usage{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var u types.Usage
+	err = item.Value(func(v []byte) error {
+		return json.Unmarshal(v, &u)
+	})
+	return &u, err
+}
+
+// 策略生效期间记录发送方的转出与铸币数量；未设置策略时不记录，
+// 计数只取决于策略设置之后的日志，节点升级时间点不影响结果
+func (s *Store) recordUsageWithTxn(txn *badger.Txn, tx types.Transaction, timestamp int64) error {
+	if tx.Type != types.TxTypeMint && tx.Type != types.TxTypeTransfer && tx.Type != types.TxTypeBurn {
+		return nil
+	}
+	p, err := s.policyWithTxn(txn)
+	if err != nil || p == nil {
+		return err
+	}
+	u, err := s.usageWithTxn(txn, tx.Sender)
+	if err != nil {
+		return err
+	}
+	if tx.Type == types.TxTypeMint {
+		u.Minted = saturatingAdd(u.Minted, tx.Amount)
+	} else {
+		day := timestamp / 86400
+		u.Spent = saturatingAdd(u.SpentOn(day), tx.Amount)
+		u.Day = day
+	}
+	val, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+	return txn.Set(append(append([]byte(nil), keyUsagePref...), tx.Sender...), val)
+}
+
+func saturatingAdd(a, b uint64) uint64 {
+	sum, carry := bits.Add64(a, b, 0)
+	if carry != 0 {
+		return ^uint64(0)
+	}
+	return sum
+}
+
+// 读取当前额度策略，未设置时返回 nil
+func (s *Store) GetPolicy() (*types.Policy, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("nil store")
+	}
+	var p *types.Policy
+	err := s.db.View(func(txn *badger.Txn) error {
+		var err error
+		p, err = s.policyWithTxn(txn)
+		return err
+	})
+	return p, err
+}
+
+// 读取账户额度使用量
+func (s *Store) GetUsage(address string) (*types.Usage, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("nil store")
+	}
+	var u *types.Usage
+	err := s.db.View(func(txn *badger.Txn) error {
+		var err error
+		u, err = s.usageWithTxn(txn, address)
+		return err
+	})
+	return u, err
+}
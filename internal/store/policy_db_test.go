@@ -0,0 +1,61 @@
+package store
+
+import (
+	"testing"
+
+	"distributed_ledger_go/pkg/types"
+)
+
+// pkg/audit 与 pkg/txhash 的黄金值测试以该策略作为 SET_POLICY 的 data，它必须是节点真正会接受的策略
+func TestParsePolicyMaxSupply(t *testing.T) {
+	p, err := ParsePolicy([]byte(`{"max_supply":1000000}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.MaxSupply != 1000000 {
+		t.Fatalf("MaxSupply = %d, want 1000000", p.MaxSupply)
+	}
+}
+
+func TestParsePolicyRejectsUnknownFields(t *testing.T) {
+	if _, err := ParsePolicy([]byte(`{"max_per_tx":10}`)); err == nil {
+		t.Fatal("expected error for unknown field")
+	}
+}
+
+// 策略生效后按 UTC 自然日累计转出，跨过 00:00 UTC 后计数归零；铸币累计不随日期重置
+func TestUsageResetsAtUTCDayBoundary(t *testing.T) {
+	s := newTestStore(t)
+	mustRegister(t, s, "alice", types.RoleCreator)
+	mustRegister(t, s, "bob", types.RoleUser)
+	const day = int64(19000) * 86400
+	mustApply(t, s, types.Transaction{Type: types.TxTypeMint, Sender: "alice", Receiver: "alice", Amount: 100, Nonce: 1}, 1, day-10)
+	// 设置策略前的交易不计入使用量
+	if u, err := s.GetUsage("alice"); err != nil || *u != (types.Usage{}) {
+		t.Fatalf("usage before policy = %+v, %v", u, err)
+	}
+	mustApply(t, s, types.Transaction{Type: types.TxTypeSetPolicy, Sender: "alice", Receiver: "alice", Nonce: 2, Data: []byte(`{"max_supply":1000000}`)}, 2, day-9)
+	mustApply(t, s, types.Transaction{Type: types.TxTypeTransfer, Sender: "alice", Receiver: "bob", Amount: 10, Nonce: 3}, 3, day-2)
+	mustApply(t, s, types.Transaction{Type: types.TxTypeBurn, Sender: "alice", Receiver: "alice", Amount: 5, Nonce: 4}, 4, day-1)
+	mustApply(t, s, types.Transaction{Type: types.TxTypeMint, Sender: "alice", Receiver: "alice", Amount: 7, Nonce: 5}, 5, day-1)
+
+	u, err := s.GetUsage("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (types.Usage{Day: day/86400 - 1, Spent: 15, Minted: 7}); *u != want {
+		t.Fatalf("usage before midnight = %+v, want %+v", *u, want)
+	}
+	if got := u.SpentOn(day / 86400); got != 0 {
+		t.Fatalf("SpentOn(next day) = %d, want 0", got)
+	}
+
+	mustApply(t, s, types.Transaction{Type: types.TxTypeTransfer, Sender: "alice", Receiver: "bob", Amount: 3, Nonce: 6}, 6, day)
+	u, err = s.GetUsage("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (types.Usage{Day: day / 86400, Spent: 3, Minted: 7}); *u != want {
+		t.Fatalf("usage after midnight = %+v, want %+v", *u, want)
+	}
+}
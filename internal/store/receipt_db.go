@@ -0,0 +1,124 @@
+package store
+
+import (
+	"distributed_ledger_go/pkg/types"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+const (
+	ReceiptPrefix = "receipt:"
+	// 失败回执按 receipt:failed:<hash>:<raftIndex(8 字节大端)> 存放，同一交易的多次失败尝试互不覆盖；
+	// 旧版本写入的 receipt:failed:<hash> 仍可读取
+	ReceiptFailedPrefix = "receipt:failed:"
+)
+
+func failedReceiptPrefix(txHash string) []byte {
+	return []byte(ReceiptFailedPrefix + txHash + ":")
+}
+
+func failedReceiptKey(txHash string, raftIndex uint64) []byte {
+	return binary.BigEndian.AppendUint64(failedReceiptPrefix(txHash), raftIndex)
+}
+
+// 按交易哈希读取回执：成功回执优先，否则返回 Raft 索引最大的一次失败尝试
+func (s *Store) GetReceipt(txHash string) (*types.Receipt, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("nil store")
+	}
+	var r *types.Receipt
+	err := s.db.View(func(txn *badger.Txn) error {
+		var err error
+		if r, err = getReceiptWithTxn(txn, []byte(ReceiptPrefix+txHash)); err != nil || r != nil {
+			return err
+		}
+		prefix := failedReceiptPrefix(txHash)
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+		opts.Reverse = true
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		it.Seek(failedReceiptKey(txHash, ^uint64(0)))
+		if it.ValidForPrefix(prefix) {
+			r = &types.Receipt{}
+			return it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, r)
+			})
+		}
+		if r, err = getReceiptWithTxn(txn, []byte(ReceiptFailedPrefix+txHash)); err != nil || r != nil {
+			return err
+		}
+		return fmt.Errorf("%w: %s", ErrReceiptNotFound, txHash)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// 读取 Raft 索引 raftIndex 处已执行交易的回执（成功或失败），没有时返回 nil
+func (s *Store) ReceiptAt(txHash string, raftIndex uint64) (*types.Receipt, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("nil store")
+	}
+	var r *types.Receipt
+	err := s.db.View(func(txn *badger.Txn) error {
+		keys := [][]byte{
+			[]byte(ReceiptPrefix + txHash),
+			failedReceiptKey(txHash, raftIndex),
+			[]byte(ReceiptFailedPrefix + txHash),
+		}
+		for _, key := range keys {
+			rec, err := getReceiptWithTxn(txn, key)
+			if err != nil {
+				return err
+			}
+			if rec != nil && rec.RaftIndex == raftIndex {
+				r = rec
+				return nil
+			}
+		}
+		return nil
+	})
+	return r, err
+}
+
+// 在给定事务中读取指定键的回执，不存在时返回 nil
+func getReceiptWithTxn(txn *badger.Txn, key []byte) (*types.Receipt, error) {
+	item, err := txn.Get(key)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var r types.Receipt
+	if err := item.Value(func(val []byte) error {
+		return json.Unmarshal(val, &r)
+	}); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// 在给定事务中保存成功回执
+func (s *Store) saveReceiptWithTxn(txn *badger.Txn, r *types.Receipt) error {
+	val, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return txn.Set([]byte(ReceiptPrefix+r.TxHash), val)
+}
+
+// 在给定事务中保存被拒绝交易的回执，按 Raft 索引区分同一交易的多次尝试
+func (s *Store) saveFailedReceiptWithTxn(txn *badger.Txn, r *types.Receipt) error {
+	val, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return txn.Set(failedReceiptKey(r.TxHash, r.RaftIndex), val)
+}
@@ -0,0 +1,68 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	badger "github.com/dgraph-io/badger/v3"
+)
+
+// 副本本地状态的键前缀（分叉记录、清除水位线、已校验水位线），只描述本节点，不进入 Raft 快照
+var localPrefix = []byte("local:")
+
+// 导出除本地状态外的全部数据，作为 Raft 快照内容
+func (s *Store) Backup(w io.Writer) error {
+	if s == nil || s.db == nil {
+		return errors.New("nil store")
+	}
+	stream := s.db.NewStream()
+	stream.LogPrefix = "store.Backup"
+	stream.ChooseKey = func(item *badger.Item) bool {
+		return !bytes.HasPrefix(item.Key(), localPrefix)
+	}
+	_, err := stream.Backup(w, 0)
+	return err
+}
+
+// 用快照替换复制状态：保留本节点的分叉记录与清除水位线，丢弃已校验水位线，
+// 审计链已被整体替换，下次启动需重新校验全链
+func (s *Store) Restore(r io.Reader) error {
+	if s == nil || s.db == nil {
+		return errors.New("nil store")
+	}
+	kept := map[string][]byte{}
+	err := s.db.View(func(txn *badger.Txn) error {
+		for _, key := range [][]byte{keyDivergence, keyDivergenceCleared} {
+			item, err := txn.Get(key)
+			if err == badger.ErrKeyNotFound {
+				continue
+			} else if err != nil {
+				return err
+			}
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			kept[string(key)] = val
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.db.DropAll(); err != nil {
+		return err
+	}
+	if err := s.db.Load(r, 10); err != nil {
+		return err
+	}
+	return s.db.Update(func(txn *badger.Txn) error {
+		for k, v := range kept {
+			if err := txn.Set([]byte(k), v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
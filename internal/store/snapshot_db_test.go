@@ -0,0 +1,80 @@
+package store
+
+import (
+	"bytes"
+	"testing"
+
+	"distributed_ledger_go/pkg/types"
+)
+
+func TestSnapshotExcludesLocalState(t *testing.T) {
+	src := newTestStore(t)
+	mustRegister(t, src, "alice", types.RoleUser)
+	if _, err := src.Append([]byte("entry")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.VerifyTail(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.SaveDivergence(&types.AuditDivergence{Index: 1, Source: "checkpoint"}); err != nil {
+		t.Fatal(err)
+	}
+	var snap bytes.Buffer
+	if err := src.Backup(&snap); err != nil {
+		t.Fatal(err)
+	}
+	data := snap.Bytes()
+
+	// 快照不携带源节点的分叉记录与水位线
+	fresh := newTestStore(t)
+	if err := fresh.Restore(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	if d, _, err := fresh.LoadDivergence(); err != nil || d != nil {
+		t.Fatalf("divergence leaked through snapshot: %+v, %v", d, err)
+	}
+	if mark, _, err := fresh.LoadVerifiedMark(); err != nil || mark != 0 {
+		t.Fatalf("verified mark leaked through snapshot: %d, %v", mark, err)
+	}
+
+	// 恢复方有自己的分叉记录与已校验水位线
+	dst := newTestStore(t)
+	for i := 0; i < 3; i++ {
+		if _, err := dst.Append([]byte("other")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := dst.VerifyTail(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dst.SaveDivergence(&types.AuditDivergence{Index: 3, Source: "quorum"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := dst.ClearDivergence(2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dst.SaveDivergence(&types.AuditDivergence{Index: 3, Source: "quorum"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := dst.Restore(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := dst.GetAccount("alice"); err != nil {
+		t.Fatalf("replicated state not restored: %v", err)
+	}
+	d, cleared, err := dst.LoadDivergence()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d == nil || d.Index != 3 || d.Source != "quorum" || cleared != 2 {
+		t.Fatalf("local divergence = %+v cleared %d, want own record kept", d, cleared)
+	}
+	// 审计链已被替换，水位线清空，下次启动重新校验全链
+	if mark, _, err := dst.LoadVerifiedMark(); err != nil || mark != 0 {
+		t.Fatalf("verified mark = %d, %v; want 0", mark, err)
+	}
+	if n, err := dst.VerifyTail(); err != nil || n != 1 {
+		t.Fatalf("VerifyTail after restore = %d, %v", n, err)
+	}
+}
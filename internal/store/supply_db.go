@@ -0,0 +1,188 @@
+package store
+
+import (
+	"distributed_ledger_go/pkg/audit"
+	"distributed_ledger_go/pkg/types"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/bits"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+// 发行量计数器，随铸币、销毁与创世状态在同一事务中更新
+var keySupply = []byte("ledger:supply")
+
+var (
+	// ErrSupplyOverflow 表示铸币后发行量超出 uint64 范围
+	ErrSupplyOverflow = errors.New("total supply overflow")
+	// ErrSupplyMismatch 表示账户余额之和与发行量计数器不一致
+	ErrSupplyMismatch = errors.New("sum of balances does not match total supply")
+)
+
+// 读取发行量；旧数据没有计数器时按账户余额与审计链中的铸币记录重新计算
+func (s *Store) supplyWithTxn(txn *badger.Txn) (*types.Supply, error) {
+	item, err := txn.Get(keySupply)
+	if err == nil {
+		var sup types.Supply
+		err := item.Value(func(v []byte) error {
+			return json.Unmarshal(v, &sup)
+		})
+		return &sup, err
+	}
+	if err != badger.ErrKeyNotFound {
+		return nil, err
+	}
+	total, err := s.sumBalancesWithTxn(txn)
+	if err != nil {
+		return nil, err
+	}
+	sup := &types.Supply{TotalSupply: total}
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = keyEntryPref
+	it := txn.NewIterator(opts)
+	defer it.Close()
+	for it.Rewind(); it.Valid(); it.Next() {
+		err := it.Item().Value(func(v []byte) error {
+			e, err := audit.DecodeEntry(v)
+			if err != nil {
+				return err
+			}
+			tx, err := audit.DecodeTransaction(e.TxBytes)
+			if err != nil {
+				return fmt.Errorf("decode audit entry %d: %w", e.Index, err)
+			}
+			switch tx.Type {
+			case types.TxTypeMint:
+				sup.Minted += tx.Amount
+			case types.TxTypeBurn:
+				sup.Burned += tx.Amount
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	// 余额中不来自铸币的部分即创世余额
+	if sup.TotalSupply+sup.Burned > sup.Minted {
+		sup.Genesis = sup.TotalSupply + sup.Burned - sup.Minted
+	}
+	return sup, nil
+}
+
+// 在给定事务中累加所有 acc: 账户余额
+func (s *Store) sumBalancesWithTxn(txn *badger.Txn) (uint64, error) {
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = []byte(AccPrefix)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+	var total uint64
+	for it.Rewind(); it.Valid(); it.Next() {
+		var acc types.Account
+		if err := it.Item().Value(func(v []byte) error {
+			return json.Unmarshal(v, &acc)
+		}); err != nil {
+			return 0, err
+		}
+		sum, carry := bits.Add64(total, acc.Balance, 0)
+		if carry != 0 {
+			return 0, ErrSupplyOverflow
+		}
+		total = sum
+	}
+	return total, nil
+}
+
+func (s *Store) saveSupplyWithTxn(txn *badger.Txn, sup *types.Supply) error {
+	val, err := json.Marshal(sup)
+	if err != nil {
+		return err
+	}
+	return txn.Set(keySupply, val)
+}
+
+// 铸币时增加发行量
+func (s *Store) mintSupplyWithTxn(txn *badger.Txn, amount uint64) error {
+	sup, err := s.supplyWithTxn(txn)
+	if err != nil {
+		return err
+	}
+	total, carry := bits.Add64(sup.TotalSupply, amount, 0)
+	if carry != 0 {
+		return fmt.Errorf("%w: supply %d, mint %d", ErrSupplyOverflow, sup.TotalSupply, amount)
+	}
+	sup.TotalSupply = total
+	sup.Minted += amount
+	return s.saveSupplyWithTxn(txn, sup)
+}
+
+// 销毁时减少发行量
+func (s *Store) burnSupplyWithTxn(txn *badger.Txn, amount uint64) error {
+	sup, err := s.supplyWithTxn(txn)
+	if err != nil {
+		return err
+	}
+	if sup.TotalSupply < amount {
+		return fmt.Errorf("%w: supply %d, burn %d", ErrSupplyMismatch, sup.TotalSupply, amount)
+	}
+	sup.TotalSupply -= amount
+	sup.Burned += amount
+	return s.saveSupplyWithTxn(txn, sup)
+}
+
+// 读取当前发行量
+func (s *Store) GetSupply() (*types.Supply, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("nil store")
+	}
+	var sup *types.Supply
+	err := s.db.View(func(txn *badger.Txn) error {
+		var err error
+		sup, err = s.supplyWithTxn(txn)
+		return err
+	})
+	return sup, err
+}
+
+// 为升级前的数据写入发行量计数器，已存在时直接返回
+func (s *Store) EnsureSupply() error {
+	if s == nil || s.db == nil {
+		return errors.New("nil store")
+	}
+	return s.db.Update(func(txn *badger.Txn) error {
+		if _, err := txn.Get(keySupply); err == nil || err != badger.ErrKeyNotFound {
+			return err
+		}
+		sup, err := s.supplyWithTxn(txn)
+		if err != nil {
+			return err
+		}
+		return s.saveSupplyWithTxn(txn, sup)
+	})
+}
+
+// 在同一只读事务中校验所有账户余额之和等于发行量，返回发行量与余额之和；不一致时返回 ErrSupplyMismatch
+func (s *Store) VerifySupply() (*types.Supply, uint64, error) {
+	if s == nil || s.db == nil {
+		return nil, 0, errors.New("nil store")
+	}
+	var sup *types.Supply
+	var total uint64
+	err := s.db.View(func(txn *badger.Txn) error {
+		var err error
+		if sup, err = s.supplyWithTxn(txn); err != nil {
+			return err
+		}
+		total, err = s.sumBalancesWithTxn(txn)
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	if total != sup.TotalSupply {
+		return sup, total, fmt.Errorf("%w: balances %d, supply %d", ErrSupplyMismatch, total, sup.TotalSupply)
+	}
+	return sup, total, nil
+}
@@ -0,0 +1,54 @@
+package store
+
+import (
+	"errors"
+	"testing"
+
+	"distributed_ledger_go/pkg/types"
+
+	badger "github.com/dgraph-io/badger/v3"
+)
+
+// 创世余额、铸币、转账、销毁与被拒绝的交易之后，账户余额之和始终等于发行量
+func TestVerifySupplyInvariant(t *testing.T) {
+	s := newTestStore(t)
+	if err := s.ApplyGenesis(&types.Genesis{Creator: "alice", Balances: map[string]uint64{"alice": 30}}, "genesis"); err != nil {
+		t.Fatal(err)
+	}
+	mustRegister(t, s, "bob", types.RoleUser)
+	txs := []types.Transaction{
+		{Type: types.TxTypeMint, Sender: "alice", Receiver: "alice", Amount: 100, Nonce: 1},
+		{Type: types.TxTypeTransfer, Sender: "alice", Receiver: "bob", Amount: 40, Nonce: 2},
+		{Type: types.TxTypeMint, Sender: "alice", Receiver: "bob", Amount: 25, Nonce: 3},
+		{Type: types.TxTypeBurn, Sender: "bob", Receiver: "bob", Amount: 15, Nonce: 1},
+	}
+	for i, tx := range txs {
+		mustApply(t, s, tx, uint64(i+1), 1000)
+	}
+	if _, err := applyTx(s, types.Transaction{Type: types.TxTypeBurn, Sender: "bob", Receiver: "bob", Amount: 1000, Nonce: 2}, 10, 1000); err == nil {
+		t.Fatal("expected rejection")
+	}
+
+	sup, total, err := s.VerifySupply()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (types.Supply{TotalSupply: 140, Genesis: 30, Minted: 125, Burned: 15}); *sup != want || total != 140 {
+		t.Fatalf("supply = %+v, balances %d; want %+v, 140", *sup, total, want)
+	}
+
+	// 绕过交易直接改动余额后，不变式检查能发现不一致
+	if err := s.db.Update(func(txn *badger.Txn) error {
+		acc, err := s.getAccountWithTxn(txn, "bob")
+		if err != nil {
+			return err
+		}
+		acc.Balance++
+		return s.saveAccountWithTxn(txn, acc)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.VerifySupply(); !errors.Is(err, ErrSupplyMismatch) {
+		t.Fatalf("got %v, want ErrSupplyMismatch", err)
+	}
+}
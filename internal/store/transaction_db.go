@@ -1,7 +1,8 @@
 package store
 
 import (
-	"distributed_ledger_go/internal/types"
+	"distributed_ledger_go/pkg/types"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,60 +10,205 @@ import (
 	"github.com/dgraph-io/badger/v3"
 )
 
-// 添加交易
-func (s *Store) ApplyTransaction(tx types.Transaction) error {
-	return s.db.Update(func(txn *badger.Txn) error {
-		// 1. 发送者账户（必须已注册）
-		senderAcc, err := s.getAccountWithTxn(txn, tx.Sender)
+// AccountLookup 在当前事务视图中读取账户。
+type AccountLookup func(address string) (*types.Account, error)
+
+// TxView 为交易校验提供的只读状态视图，FSM 中各读取函数与写入处于同一事务。
+type TxView struct {
+	Account AccountLookup
+	// Policy 读取当前额度策略，未设置时返回 nil
+	Policy func() (*types.Policy, error)
+	// Usage 读取账户的额度使用量
+	Usage  func(address string) (*types.Usage, error)
+	Supply func() (*types.Supply, error)
+}
+
+// TxCheck 在写入前对交易做业务校验，读取的状态与写入处于同一事务。
+type TxCheck func(view TxView) error
+
+// 在给定事务上构造只读视图
+func (s *Store) txViewWithTxn(txn *badger.Txn) TxView {
+	return TxView{
+		Account: func(address string) (*types.Account, error) {
+			return s.getAccountWithTxn(txn, address)
+		},
+		Policy: func() (*types.Policy, error) {
+			return s.policyWithTxn(txn)
+		},
+		Usage: func(address string) (*types.Usage, error) {
+			return s.usageWithTxn(txn, address)
+		},
+		Supply: func() (*types.Supply, error) {
+			return s.supplyWithTxn(txn)
+		},
+	}
+}
+
+// 添加交易：校验、写审计与余额变更在同一个 Badger 事务中提交，任一步失败均整体回滚。
+// receipt 由调用方填入交易哈希与 Raft 索引，成功后补全审计位置与余额并随事务一起持久化；
+// 交易被拒绝时丢弃其全部写入，本次提交只落盘失败回执，receipt 被改写为该失败回执并返回拒绝原因。
+// 返回错误而 receipt.Status 不是 failed 时，说明回执未能落盘（存储错误）。
+// meta 为写入审计条目的时间戳、任期与 Raft 索引。
+func (s *Store) ApplyTransaction(tx types.Transaction, check TxCheck, auditPayload []byte, meta AuditMeta, receipt *types.Receipt) error {
+	if s == nil || s.db == nil {
+		return errors.New("nil store")
+	}
+	txn := s.db.NewTransaction(true)
+	defer func() { txn.Discard() }()
+	rejected := s.applyTransactionWithTxn(txn, tx, check, auditPayload, meta, receipt)
+	if rejected == nil {
+		return txn.Commit()
+	}
+	if receipt == nil {
+		return rejected
+	}
+	// badger 事务无法部分回滚，换一个事务只写失败回执，保证每个 Raft 条目恰好提交一次
+	txn.Discard()
+	txn = s.db.NewTransaction(true)
+	failed := types.Receipt{
+		TxHash:    receipt.TxHash,
+		Status:    types.ReceiptStatusFailed,
+		Error:     rejected.Error(),
+		RaftIndex: receipt.RaftIndex,
+	}
+	if err := s.saveFailedReceiptWithTxn(txn, &failed); err != nil {
+		return err
+	}
+	if err := txn.Commit(); err != nil {
+		return err
+	}
+	*receipt = failed
+	return rejected
+}
+
+// 在给定事务中校验并执行交易，写入审计条目、流水索引与成功回执
+func (s *Store) applyTransactionWithTxn(txn *badger.Txn, tx types.Transaction, check TxCheck, auditPayload []byte, meta AuditMeta, receipt *types.Receipt) error {
+	if check != nil {
+		if err := check(s.txViewWithTxn(txn)); err != nil {
+			return err
+		}
+	}
+	senderAcc, receiverAcc, err := s.applyWithTxn(txn, tx)
+	if err != nil {
+		return err
+	}
+	if err := s.recordUsageWithTxn(txn, tx, meta.Timestamp); err != nil {
+		return err
+	}
+	if auditPayload != nil {
+		e, err := s.appendWithTxn(txn, append([]byte(nil), auditPayload...), meta)
 		if err != nil {
 			return err
 		}
-
-		// 仅转账需要余额/冻结校验
-		if tx.Type == types.TxTypeTransfer {
-			if senderAcc.Balance < tx.Amount {
-				return errors.New("insufficient balance")
-			}
-			if senderAcc.IsFrozen {
-				return errors.New("sender account is frozen")
-			}
-			senderAcc.Balance -= tx.Amount
+		if receipt != nil {
+			receipt.AuditIndex = e.Index
+			receipt.EntryHash = hex.EncodeToString(e.EntryHash[:])
 		}
+		if err := s.indexHistoryWithTxn(txn, historyRecord(e.Index, e.Timestamp, tx)); err != nil {
+			return err
+		}
+	}
+	if receipt == nil {
+		return nil
+	}
+	receipt.Status = types.ReceiptStatusSuccess
+	receipt.SenderBalance = senderAcc.Balance
+	receipt.ReceiverBalance = receiverAcc.Balance
+	return s.saveReceiptWithTxn(txn, receipt)
+}
+
+// 在给定事务中执行交易的状态变更，返回变更后的发送者与接收者账户
+func (s *Store) applyWithTxn(txn *badger.Txn, tx types.Transaction) (*types.Account, *types.Account, error) {
+	// 1. 发送者账户（必须已注册）
+	senderAcc, err := s.getAccountWithTxn(txn, tx.Sender)
+	if err != nil {
+		return nil, nil, err
+	}
 
-		// 仅 MINT/TRANSFER 需要 nonce 校验与递增
-		if tx.Type == types.TxTypeMint || tx.Type == types.TxTypeTransfer {
-			if tx.Nonce != senderAcc.Nonce+1 {
-				return fmt.Errorf("nonce mismatch: expected %d, got %d", senderAcc.Nonce+1, tx.Nonce)
-			}
-			senderAcc.Nonce++
+	// 转账与销毁需要余额/冻结校验
+	if tx.Type == types.TxTypeTransfer || tx.Type == types.TxTypeBurn {
+		if senderAcc.Balance < tx.Amount {
+			return nil, nil, ErrInsufficientBalance
+		}
+		if senderAcc.IsFrozen {
+			return nil, nil, fmt.Errorf("%w: sender %s", ErrFrozen, tx.Sender)
 		}
+		senderAcc.Balance -= tx.Amount
+	}
+
+	// 所有交易类型都需要 nonce 校验与递增，防止重放
+	if tx.Nonce != senderAcc.Nonce+1 {
+		return nil, nil, fmt.Errorf("%w: expected %d, got %d", ErrNonceMismatch, senderAcc.Nonce+1, tx.Nonce)
+	}
+	senderAcc.Nonce++
 
-		// 2. 接收者账户（必须已注册）
-		receiverAcc, err := s.getAccountWithTxn(txn, tx.Receiver)
+	// 2. 接收者账户（必须已注册）；自转账时复用发送者，避免后写覆盖 nonce
+	receiverAcc := senderAcc
+	if tx.Receiver != tx.Sender {
+		receiverAcc, err = s.getAccountWithTxn(txn, tx.Receiver)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
+	}
 
-		// 3. 执行业务
-		switch tx.Type {
-		case types.TxTypeTransfer:
-			receiverAcc.Balance += tx.Amount
-		case types.TxTypeFreeze:
-			receiverAcc.IsFrozen = true
-		case types.TxTypeUnfreeze:
-			receiverAcc.IsFrozen = false
-		case types.TxTypeMint:
-			receiverAcc.Balance += tx.Amount
-		default:
-			return errors.New("unknown transaction type")
+	// 3. 执行业务
+	switch tx.Type {
+	case types.TxTypeTransfer:
+		receiverAcc.Balance += tx.Amount
+	case types.TxTypeFreeze:
+		receiverAcc.IsFrozen = true
+	case types.TxTypeUnfreeze:
+		receiverAcc.IsFrozen = false
+	case types.TxTypeMint:
+		// 发行量须在接收者余额落盘前更新，计数器缺失时按事务内的余额之和初始化
+		if err := s.mintSupplyWithTxn(txn, tx.Amount); err != nil {
+			return nil, nil, err
 		}
-
-		// 4. 持久化
-		if err := s.saveAccountWithTxn(txn, senderAcc); err != nil {
-			return err
+		receiverAcc.Balance += tx.Amount
+	case types.TxTypeBurn:
+		// 销毁的余额已在上面从发送方扣除，接收者即发送方本身
+		if tx.Receiver != tx.Sender {
+			return nil, nil, errors.New("burn receiver must be the sender")
 		}
-		return s.saveAccountWithTxn(txn, receiverAcc)
-	})
+		if err := s.burnSupplyWithTxn(txn, tx.Amount); err != nil {
+			return nil, nil, err
+		}
+	case types.TxTypeSetPolicy:
+		if tx.Receiver != tx.Sender {
+			return nil, nil, errors.New("set policy receiver must be the sender")
+		}
+		p, err := ParsePolicy(tx.Data)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := s.savePolicyWithTxn(txn, p); err != nil {
+			return nil, nil, err
+		}
+	default:
+		return nil, nil, ErrUnknownTxType
+	}
+
+	// 4. 持久化
+	if err := s.saveAccountWithTxn(txn, senderAcc); err != nil {
+		return nil, nil, err
+	}
+	if err := s.saveAccountWithTxn(txn, receiverAcc); err != nil {
+		return nil, nil, err
+	}
+	return senderAcc, receiverAcc, nil
+}
+
+// 由交易生成流水索引记录
+func historyRecord(index uint64, timestamp int64, tx types.Transaction) *types.HistoryRecord {
+	return &types.HistoryRecord{
+		Index:     index,
+		Timestamp: timestamp,
+		Type:      tx.Type,
+		Sender:    tx.Sender,
+		Receiver:  tx.Receiver,
+		Amount:    tx.Amount,
+		Nonce:     tx.Nonce,
+	}
 }
 
 // 读取账户（未注册则报错）
@@ -71,7 +217,7 @@ func (s *Store) getAccountWithTxn(txn *badger.Txn, address string) (*types.Accou
 	item, err := txn.Get(key)
 	if err != nil {
 		if err == badger.ErrKeyNotFound {
-			return nil, fmt.Errorf("account not registered: %s", address)
+			return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, address)
 		}
 		return nil, err
 	}
@@ -82,28 +228,6 @@ func (s *Store) getAccountWithTxn(txn *badger.Txn, address string) (*types.Accou
 	return &acc, err
 }
 
-// 注册账户
-func (s *Store) RegisterAccount(address string) error {
-	return s.db.Update(func(txn *badger.Txn) error {
-		key := []byte("acc:" + address)
-		_, err := txn.Get(key)
-		if err == nil {
-			return errors.New("account already exists")
-		}
-		if err != badger.ErrKeyNotFound {
-			return err
-		}
-		acc := &types.Account{
-			Address:  address,
-			Balance:  0,
-			Nonce:    0,
-			IsFrozen: false,
-		}
-		val, _ := json.Marshal(acc)
-		return txn.Set(key, val)
-	})
-}
-
 // 内部复用事务保存账户序列化数据
 func (s *Store) saveAccountWithTxn(txn *badger.Txn, acc *types.Account) error {
 	key := []byte("acc:" + acc.Address)
@@ -0,0 +1,132 @@
+package store
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"distributed_ledger_go/pkg/types"
+)
+
+// 被拒绝的交易不改变任何状态（余额、nonce、发行量、审计链、流水），但仍按 Raft 索引落盘失败回执
+func TestApplyTransactionRejectedRollsBack(t *testing.T) {
+	s := newTestStore(t)
+	mustRegister(t, s, "alice", types.RoleCreator)
+	mustRegister(t, s, "bob", types.RoleUser)
+	mustApply(t, s, types.Transaction{Type: types.TxTypeMint, Sender: "alice", Receiver: "alice", Amount: 100, Nonce: 1}, 1, 1000)
+
+	aliceBefore, bobBefore := mustAccount(t, s, "alice"), mustAccount(t, s, "bob")
+	supBefore, err := s.GetSupply()
+	if err != nil {
+		t.Fatal(err)
+	}
+	headBefore, hashBefore, err := s.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 分别在余额校验、读取接收方（发送方余额与 nonce 已在事务内改动）与累加发行量时被拒绝
+	rejected := []types.Transaction{
+		{Type: types.TxTypeTransfer, Sender: "alice", Receiver: "bob", Amount: 500, Nonce: 2},
+		{Type: types.TxTypeTransfer, Sender: "alice", Receiver: "carol", Amount: 10, Nonce: 2},
+		{Type: types.TxTypeMint, Sender: "alice", Receiver: "bob", Amount: ^uint64(0), Nonce: 2},
+	}
+	for i, tx := range rejected {
+		raftIndex := uint64(10 + i)
+		receipt, err := applyTx(s, tx, raftIndex, 2000)
+		if err == nil {
+			t.Fatalf("tx %d: expected rejection", i)
+		}
+		if receipt.Status != types.ReceiptStatusFailed || receipt.Error != err.Error() || receipt.RaftIndex != raftIndex {
+			t.Fatalf("tx %d: receipt = %+v", i, receipt)
+		}
+		stored, err := s.ReceiptAt(receipt.TxHash, raftIndex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(stored, receipt) {
+			t.Fatalf("tx %d: stored receipt %+v, want %+v", i, stored, receipt)
+		}
+	}
+
+	if got := mustAccount(t, s, "alice"); !reflect.DeepEqual(got, aliceBefore) {
+		t.Fatalf("alice = %+v, want %+v", got, aliceBefore)
+	}
+	if got := mustAccount(t, s, "bob"); !reflect.DeepEqual(got, bobBefore) {
+		t.Fatalf("bob = %+v, want %+v", got, bobBefore)
+	}
+	if sup, err := s.GetSupply(); err != nil || !reflect.DeepEqual(sup, supBefore) {
+		t.Fatalf("supply = %+v, %v; want %+v", sup, err, supBefore)
+	}
+	if head, hash, err := s.Head(); err != nil || head != headBefore || hash != hashBefore {
+		t.Fatalf("audit head = %d %x, %v; want %d %x", head, hash, err, headBefore, hashBefore)
+	}
+	if recs, _, err := s.ListHistory("bob", HistoryFilter{Limit: 10}); err != nil || len(recs) != 0 {
+		t.Fatalf("bob history = %+v, %v", recs, err)
+	}
+	if _, err := s.GetAccount("carol"); !errors.Is(err, ErrAccountNotFound) {
+		t.Fatalf("carol: got %v, want ErrAccountNotFound", err)
+	}
+
+	// 拒绝后 nonce 未被消耗，同一 nonce 的合法交易仍可执行
+	mustApply(t, s, types.Transaction{Type: types.TxTypeTransfer, Sender: "alice", Receiver: "bob", Amount: 30, Nonce: 2}, 20, 3000)
+	if got := mustAccount(t, s, "bob").Balance; got != 30 {
+		t.Fatalf("bob balance = %d, want 30", got)
+	}
+}
+
+// 同一交易的多次失败尝试按 Raft 索引分别保存，ReceiptAt 只返回对应索引的回执
+func TestReceiptAtDistinguishesRaftIndex(t *testing.T) {
+	s := newTestStore(t)
+	mustRegister(t, s, "alice", types.RoleCreator)
+	tx := types.Transaction{Type: types.TxTypeTransfer, Sender: "alice", Receiver: "alice", Amount: 5, Nonce: 1}
+	first, err := applyTx(s, tx, 3, 1000)
+	if !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("got %v, want ErrInsufficientBalance", err)
+	}
+	if r, err := s.ReceiptAt(first.TxHash, 4); err != nil || r != nil {
+		t.Fatalf("ReceiptAt(4) before apply = %+v, %v", r, err)
+	}
+	mustApply(t, s, types.Transaction{Type: types.TxTypeMint, Sender: "alice", Receiver: "alice", Amount: 10, Nonce: 1}, 4, 1000)
+	tx.Nonce = 2
+	second := mustApply(t, s, tx, 5, 1000)
+
+	if r, err := s.ReceiptAt(first.TxHash, 3); err != nil || r == nil || r.Status != types.ReceiptStatusFailed {
+		t.Fatalf("ReceiptAt(3) = %+v, %v", r, err)
+	}
+	if r, err := s.ReceiptAt(second.TxHash, 5); err != nil || !reflect.DeepEqual(r, second) {
+		t.Fatalf("ReceiptAt(5) = %+v, %v; want %+v", r, err, second)
+	}
+	if r, err := s.GetReceipt(second.TxHash); err != nil || r.Status != types.ReceiptStatusSuccess {
+		t.Fatalf("GetReceipt = %+v, %v", r, err)
+	}
+}
+
+// 销毁与设置策略只能作用于发送方自身
+func TestApplyTransactionRejectsForeignReceiver(t *testing.T) {
+	s := newTestStore(t)
+	mustRegister(t, s, "alice", types.RoleCreator)
+	mustRegister(t, s, "bob", types.RoleUser)
+	mustApply(t, s, types.Transaction{Type: types.TxTypeMint, Sender: "alice", Receiver: "alice", Amount: 100, Nonce: 1}, 1, 1000)
+
+	tests := []struct {
+		name string
+		tx   types.Transaction
+	}{
+		{"burn", types.Transaction{Type: types.TxTypeBurn, Sender: "alice", Receiver: "bob", Amount: 10, Nonce: 2}},
+		{"set policy", types.Transaction{Type: types.TxTypeSetPolicy, Sender: "alice", Receiver: "bob", Nonce: 2, Data: []byte(`{"max_supply":1}`)}},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := applyTx(s, tt.tx, uint64(2+i), 2000); err == nil {
+				t.Fatal("expected rejection")
+			}
+		})
+	}
+	if got := mustAccount(t, s, "alice"); got.Balance != 100 || got.Nonce != 1 {
+		t.Fatalf("alice = %+v", got)
+	}
+	if p, err := s.GetPolicy(); err != nil || p != nil {
+		t.Fatalf("policy = %+v, %v; want none", p, err)
+	}
+}
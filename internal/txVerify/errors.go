@@ -0,0 +1,15 @@
+package txVerify
+
+import "errors"
+
+// 交易校验错误；余额、nonce、冻结等状态相关错误复用 store 中的定义（如 store.ErrInsufficientBalance）。
+var (
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrChainMismatch    = errors.New("chain id mismatch")
+	ErrInvalidSignature = errors.New("signature verification failed")
+	ErrTxExpired        = errors.New("transaction expired")
+	ErrPermissionDenied = errors.New("permission denied")
+	ErrInvalidReceiver  = errors.New("invalid receiver")
+	ErrLimitExceeded    = errors.New("limit exceeded")
+	ErrUnexpectedData   = errors.New("unexpected transaction data")
+)
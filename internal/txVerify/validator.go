@@ -2,59 +2,121 @@ package txVerify
 
 import (
 	"distributed_ledger_go/internal/store"
-	"distributed_ledger_go/internal/types"
 	"distributed_ledger_go/pkg/crypto"
+	"distributed_ledger_go/pkg/txhash"
+	"distributed_ledger_go/pkg/types"
 	"encoding/hex"
 	"errors"
 	"fmt"
 )
 
 type Validator struct {
-	store *store.Store
+	store   *store.Store
+	chainID string
 }
 
-func NewValidator(s *store.Store) *Validator {
-	return &Validator{store: s}
+func NewValidator(s *store.Store, chainID string) *Validator {
+	return &Validator{store: s, chainID: chainID}
 }
 
-// 验证交易
-func (v *Validator) ValidateTransaction(tx types.Transaction) error {
-	if (tx.Type == types.TxTypeMint || tx.Type == types.TxTypeTransfer) && tx.Amount == 0 {
-		return errors.New("invalid amount")
+// 返回本节点所属网络的 chain id
+func (v *Validator) ChainID() string {
+	return v.chainID
+}
+
+// 使用指定的状态视图验证交易，FSM 中传入与写入同一事务的视图以保证确定性；
+// now 为判断过期与额度所属自然日的参考时间（Unix 秒），FSM 中使用 leader 提议时写入日志的时间，为 0 时不做过期校验
+func (v *Validator) ValidateWith(view store.TxView, tx types.Transaction, now int64) error {
+	return v.validateWith(view, tx, now, false)
+}
+
+// 验证旧日志中没有 chain id 的交易，签名按 txhash.LegacyTxHash 校验，其余规则与 ValidateWith 相同
+func (v *Validator) ValidateLegacyWith(view store.TxView, tx types.Transaction, now int64) error {
+	return v.validateWith(view, tx, now, true)
+}
+
+func (v *Validator) validateWith(view store.TxView, tx types.Transaction, now int64, legacy bool) error {
+	if (tx.Type == types.TxTypeMint || tx.Type == types.TxTypeTransfer || tx.Type == types.TxTypeBurn) && tx.Amount == 0 {
+		return ErrInvalidAmount
+	}
+	if tx.Type == types.TxTypeSetPolicy && tx.Amount != 0 {
+		return fmt.Errorf("%w: set policy amount must be 0", ErrInvalidAmount)
+	}
+	// 其余类型的签名不覆盖 Data，携带数据的交易可能被篡改，直接拒绝
+	if tx.Type != types.TxTypeSetPolicy && len(tx.Data) > 0 {
+		return fmt.Errorf("%w: only txType=%d may carry data", ErrUnexpectedData, types.TxTypeSetPolicy)
+	}
+	if legacy {
+		if tx.ChainID != "" {
+			return fmt.Errorf("%w: legacy transaction carries chain id %q", ErrChainMismatch, tx.ChainID)
+		}
+		if _, err := v.LegacySigningHash(tx); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+		}
+	} else {
+		if tx.ChainID != v.chainID {
+			return fmt.Errorf("%w: expected %q, got %q", ErrChainMismatch, v.chainID, tx.ChainID)
+		}
+		if err := v.VerifySignature(tx); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+		}
+	}
+	if tx.ValidUntil != 0 && now != 0 && now > tx.ValidUntil {
+		return ErrTxExpired
+	}
+
+	// 所有交易类型都校验 nonce，防止已签名交易被重放
+	senderAcc, err := view.Account(tx.Sender)
+	if err != nil {
+		return fmt.Errorf("sender %w", err)
 	}
-	if err := v.VerifySignature(tx); err != nil {
-		return fmt.Errorf("signature verification failed: %v", err)
+	if tx.Nonce != senderAcc.Nonce+1 {
+		return fmt.Errorf("%w: expected %d, got %d (possible replay attack)", store.ErrNonceMismatch, senderAcc.Nonce+1, tx.Nonce)
 	}
 
 	switch tx.Type {
 	case types.TxTypeMint:
-		senderAcc, err := v.store.GetAccount(tx.Sender)
-		if err != nil {
-			return errors.New("sender account not found")
-		}
-		if tx.Nonce != senderAcc.Nonce+1 {
-			return errors.New("invalid nonce: possible replay attack")
+		if err := v.validatePermission(senderAcc, tx.Type); err != nil {
+			return err
 		}
-		return v.validatePermission(tx.Type, tx.Sender)
+		return v.validateLimits(view, senderAcc, tx, now)
 
 	case types.TxTypeTransfer:
-		senderAcc, err := v.store.GetAccount(tx.Sender)
-		if err != nil {
-			return errors.New("sender account not found")
+		if err := v.validateTransfer(senderAcc, tx); err != nil {
+			return err
 		}
-		if tx.Nonce != senderAcc.Nonce+1 {
-			return errors.New("invalid nonce: possible replay attack")
-		}
-		return v.validateTransfer(senderAcc, tx)
+		return v.validateLimits(view, senderAcc, tx, now)
 
 	case types.TxTypeFreeze:
-		return v.validatePermission(tx.Type, tx.Sender)
+		return v.validatePermission(senderAcc, tx.Type)
 
 	case types.TxTypeUnfreeze:
-		return v.validatePermission(tx.Type, tx.Sender)
+		return v.validatePermission(senderAcc, tx.Type)
+
+	case types.TxTypeBurn:
+		if tx.Receiver != tx.Sender {
+			return fmt.Errorf("%w: burn receiver must be the sender", ErrInvalidReceiver)
+		}
+		if err := v.validatePermission(senderAcc, tx.Type); err != nil {
+			return err
+		}
+		if err := v.validateTransfer(senderAcc, tx); err != nil {
+			return err
+		}
+		return v.validateLimits(view, senderAcc, tx, now)
+
+	case types.TxTypeSetPolicy:
+		if tx.Receiver != tx.Sender {
+			return fmt.Errorf("%w: set policy receiver must be the sender", ErrInvalidReceiver)
+		}
+		if err := v.validatePermission(senderAcc, tx.Type); err != nil {
+			return err
+		}
+		_, err := store.ParsePolicy(tx.Data)
+		return err
 
 	default:
-		return errors.New("unknown transaction type")
+		return store.ErrUnknownTxType
 	}
 }
 
@@ -65,7 +127,7 @@ func (v *Validator) VerifySignature(tx types.Transaction) error {
 		return errors.New("invalid sender address: not hex")
 	}
 
-	hash := TxHash(tx)
+	hash := txhash.TxHash(tx)
 	pubKey, err := crypto.BytesToPublishKey(pubBytes)
 	if err != nil {
 		return err
@@ -76,25 +138,107 @@ func (v *Validator) VerifySignature(tx types.Transaction) error {
 	return nil
 }
 
-// 验证转账（账户是否冻结）
+// 返回旧交易签名覆盖的哈希，依次尝试引入 valid_until 前后的两种载荷，均不匹配时返回错误
+func (v *Validator) LegacySigningHash(tx types.Transaction) ([]byte, error) {
+	pubKey, err := crypto.HexToPublicKey(tx.Sender)
+	if err != nil {
+		return txhash.LegacyTxHash(tx, false), errors.New("invalid sender address")
+	}
+	for _, withValidUntil := range []bool{false, true} {
+		hash := txhash.LegacyTxHash(tx, withValidUntil)
+		if crypto.VerifyASN1Signature(pubKey, hash, tx.Signature) {
+			return hash, nil
+		}
+	}
+	return txhash.LegacyTxHash(tx, false), errors.New("ECDSA verification failed")
+}
+
+// 验证签名请求（流水查询与运维接口），签名绑定请求的 HTTP 方法、路径与 nonce
+func (v *Validator) VerifyRequestSignature(requester, method, path string, timestamp int64, nonce string, signature []byte) error {
+	pubKey, err := crypto.HexToPublicKey(requester)
+	if err != nil {
+		return errors.New("invalid requester address")
+	}
+	if !crypto.VerifyASN1Signature(pubKey, txhash.RequestHash(v.chainID, requester, method, path, timestamp, nonce), signature) {
+		return errors.New("ECDSA verification failed")
+	}
+	return nil
+}
+
+// 验证创世者对角色变更请求的签名
+func (v *Validator) VerifyRoleSignature(creator, target, role string, nonce uint64, signature []byte) error {
+	pubKey, err := crypto.HexToPublicKey(creator)
+	if err != nil {
+		return errors.New("invalid creator address")
+	}
+	if !crypto.VerifyASN1Signature(pubKey, txhash.RoleHash(v.chainID, creator, target, role, nonce), signature) {
+		return errors.New("ECDSA verification failed")
+	}
+	return nil
+}
+
+// 验证转账与销毁（账户是否冻结、余额是否充足）
 func (v *Validator) validateTransfer(sender *types.Account, tx types.Transaction) error {
 	if sender.IsFrozen {
-		return errors.New("account is frozen")
+		return fmt.Errorf("%w: sender %s", store.ErrFrozen, sender.Address)
 	}
 	if sender.Balance < tx.Amount {
-		return errors.New("insufficient balance")
+		return fmt.Errorf("%w: balance %d, amount %d", store.ErrInsufficientBalance, sender.Balance, tx.Amount)
 	}
 	return nil
 }
 
 // 验证是否是创始者或管理员
-func (v *Validator) validatePermission(txType types.TxType, sender string) error {
-	role, err := v.store.GetRole(sender)
+func (v *Validator) validatePermission(acc *types.Account, txType types.TxType) error {
+	// 如果是新账户，默认角色是 USER
+	role := acc.Role
+	if role == "" {
+		role = types.RoleUser
+	}
+	if !types.CanRoleExecute(txType, role) {
+		return fmt.Errorf("%w: %s cannot perform txType=%d", ErrPermissionDenied, acc.Address, txType)
+	}
+	return nil
+}
+
+// 验证额度策略：单笔上限、当日转出上限、累计铸币上限与全网发行量上限；未设置策略时不限制
+func (v *Validator) validateLimits(view store.TxView, sender *types.Account, tx types.Transaction, now int64) error {
+	policy, err := view.Policy()
+	if err != nil || policy == nil {
+		return err
+	}
+	limits := policy.LimitsFor(sender.Address, sender.Role)
+	if limits.MaxAmount != 0 && tx.Amount > limits.MaxAmount {
+		return fmt.Errorf("%w: amount %d exceeds single transaction limit %d", ErrLimitExceeded, tx.Amount, limits.MaxAmount)
+	}
+	usage, err := view.Usage(sender.Address)
 	if err != nil {
 		return err
 	}
-	if !types.CanRoleExecute(txType, role) {
-		return fmt.Errorf("permission denied: %s cannot perform txType=%d", sender, txType)
+
+	if tx.Type != types.TxTypeMint {
+		spent := usage.SpentOn(now / 86400)
+		if exceeds(spent, tx.Amount, limits.DailyLimit) {
+			return fmt.Errorf("%w: daily limit %d, spent %d, amount %d", ErrLimitExceeded, limits.DailyLimit, spent, tx.Amount)
+		}
+		return nil
+	}
+	if exceeds(usage.Minted, tx.Amount, limits.MintCap) {
+		return fmt.Errorf("%w: mint cap %d, minted %d, amount %d", ErrLimitExceeded, limits.MintCap, usage.Minted, tx.Amount)
+	}
+	if policy.MaxSupply != 0 {
+		sup, err := view.Supply()
+		if err != nil {
+			return err
+		}
+		if exceeds(sup.TotalSupply, tx.Amount, policy.MaxSupply) {
+			return fmt.Errorf("%w: max supply %d, supply %d, amount %d", ErrLimitExceeded, policy.MaxSupply, sup.TotalSupply, tx.Amount)
+		}
 	}
 	return nil
 }
+
+// 判断 used + amount 是否超过 limit（limit 为 0 表示不限），避免加法溢出
+func exceeds(used, amount, limit uint64) bool {
+	return limit != 0 && (used > limit || amount > limit-used)
+}
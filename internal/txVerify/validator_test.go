@@ -0,0 +1,180 @@
+package txVerify
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"distributed_ledger_go/internal/store"
+	"distributed_ledger_go/pkg/audit"
+	"distributed_ledger_go/pkg/crypto"
+	"distributed_ledger_go/pkg/txhash"
+	"distributed_ledger_go/pkg/types"
+
+	badger "github.com/dgraph-io/badger/v3"
+)
+
+// ledger 为在临时 Badger 上按 FSM 方式执行签名交易的测试夹具
+type ledger struct {
+	t         *testing.T
+	store     *store.Store
+	validator *Validator
+	keys      map[string]*ecdsa.PrivateKey
+	nonces    map[string]uint64
+	raftIndex uint64
+}
+
+func newLedger(t *testing.T) *ledger {
+	t.Helper()
+	db, err := badger.Open(badger.DefaultOptions(t.TempDir()).WithLogger(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	s := store.NewStore(db)
+	return &ledger{t: t, store: s, validator: NewValidator(s, "ledger-dev"), keys: map[string]*ecdsa.PrivateKey{}, nonces: map[string]uint64{}}
+}
+
+// account 注册指定角色的新账户并返回其地址
+func (l *ledger) account(role string) string {
+	l.t.Helper()
+	priv, addr, err := crypto.GenerateKeyPair()
+	if err != nil {
+		l.t.Fatal(err)
+	}
+	if _, err := l.store.RegisterWithRole(addr, role); err != nil {
+		l.t.Fatal(err)
+	}
+	l.keys[addr] = priv
+	return addr
+}
+
+// apply 签名并在 now 时刻执行交易，nonce 仅在成功时推进
+func (l *ledger) apply(txType types.TxType, sender, receiver string, amount uint64, data []byte, now int64) error {
+	l.t.Helper()
+	tx := types.Transaction{ChainID: "ledger-dev", Type: txType, Sender: sender, Receiver: receiver, Amount: amount, Nonce: l.nonces[sender] + 1, Data: data}
+	sig, err := crypto.Sign(l.keys[sender], txhash.TxHash(tx))
+	if err != nil {
+		l.t.Fatal(err)
+	}
+	tx.Signature = sig
+	l.raftIndex++
+	receipt := &types.Receipt{TxHash: hex.EncodeToString(txhash.TxHash(tx)), RaftIndex: l.raftIndex}
+	check := func(view store.TxView) error { return l.validator.ValidateWith(view, tx, now) }
+	meta := store.AuditMeta{Timestamp: now, Term: 1, RaftIndex: l.raftIndex}
+	err = l.store.ApplyTransaction(tx, check, audit.EncodeTransaction(tx), meta, receipt)
+	if err == nil {
+		l.nonces[sender]++
+	}
+	return err
+}
+
+func (l *ledger) mustApply(txType types.TxType, sender, receiver string, amount uint64, data []byte, now int64) {
+	l.t.Helper()
+	if err := l.apply(txType, sender, receiver, amount, data, now); err != nil {
+		l.t.Fatalf("txType=%d amount=%d: %v", txType, amount, err)
+	}
+}
+
+// 升级前的交易只能按旧载荷校验，且旧签名不能冒充新格式
+func TestLegacySigningHash(t *testing.T) {
+	priv, addr, err := crypto.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := NewValidator(nil, "ledger-dev")
+	for _, withValidUntil := range []bool{false, true} {
+		tx := types.Transaction{Type: types.TxTypeTransfer, Sender: addr, Receiver: addr, Amount: 1, Nonce: 1, ValidUntil: 1700000000}
+		want := txhash.LegacyTxHash(tx, withValidUntil)
+		if tx.Signature, err = crypto.Sign(priv, want); err != nil {
+			t.Fatal(err)
+		}
+		got, err := v.LegacySigningHash(tx)
+		if err != nil {
+			t.Fatalf("withValidUntil=%v: %v", withValidUntil, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("withValidUntil=%v: hash %x, want %x", withValidUntil, got, want)
+		}
+		if err := v.VerifySignature(tx); err == nil {
+			t.Fatalf("withValidUntil=%v: legacy signature accepted by current format", withValidUntil)
+		}
+	}
+
+	tx := types.Transaction{ChainID: "ledger-dev", Type: types.TxTypeTransfer, Sender: addr, Receiver: addr, Amount: 1, Nonce: 1}
+	if tx.Signature, err = crypto.Sign(priv, txhash.TxHash(tx)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.LegacySigningHash(tx); err == nil {
+		t.Fatal("current signature accepted by legacy format")
+	}
+}
+
+// 累计铸币上限与全网发行量上限都在写入前拦截，拒绝后余额之和仍等于发行量
+func TestMintCapAndMaxSupply(t *testing.T) {
+	const now = 1700000000
+	tests := []struct {
+		name    string
+		policy  string
+		minted  uint64
+		attempt uint64
+	}{
+		{"mint cap", `{"roles":{"CREATOR":{"mint_cap":120}}}`, 100, 30},
+		{"max supply", `{"max_supply":150}`, 100, 60},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newLedger(t)
+			creator := l.account(types.RoleCreator)
+			l.mustApply(types.TxTypeSetPolicy, creator, creator, 0, []byte(tt.policy), now)
+			l.mustApply(types.TxTypeMint, creator, creator, tt.minted, nil, now)
+			if err := l.apply(types.TxTypeMint, creator, creator, tt.attempt, nil, now); !errors.Is(err, ErrLimitExceeded) {
+				t.Fatalf("got %v, want ErrLimitExceeded", err)
+			}
+			l.mustApply(types.TxTypeMint, creator, creator, 20, nil, now)
+			sup, total, err := l.store.VerifySupply()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sup.TotalSupply != tt.minted+20 || total != sup.TotalSupply {
+				t.Fatalf("supply = %+v, balances %d", sup, total)
+			}
+		})
+	}
+}
+
+// 当日转出上限按 UTC 自然日计算，跨过 00:00 UTC 后额度恢复
+func TestDailyLimitResetsAtUTCMidnight(t *testing.T) {
+	const midnight = int64(19000) * 86400
+	l := newLedger(t)
+	creator := l.account(types.RoleCreator)
+	user := l.account(types.RoleUser)
+	l.mustApply(types.TxTypeMint, creator, creator, 200, nil, midnight-100)
+	l.mustApply(types.TxTypeTransfer, creator, user, 200, nil, midnight-100)
+	l.mustApply(types.TxTypeSetPolicy, creator, creator, 0, []byte(`{"roles":{"USER":{"daily_limit":50}}}`), midnight-100)
+
+	l.mustApply(types.TxTypeTransfer, user, creator, 50, nil, midnight-2)
+	if err := l.apply(types.TxTypeTransfer, user, creator, 1, nil, midnight-1); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("before midnight: got %v, want ErrLimitExceeded", err)
+	}
+	l.mustApply(types.TxTypeTransfer, user, creator, 50, nil, midnight)
+	if err := l.apply(types.TxTypeTransfer, user, creator, 1, nil, midnight+86399); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("after midnight: got %v, want ErrLimitExceeded", err)
+	}
+}
+
+// 销毁与设置策略的接收方必须是发送方本人
+func TestRejectForeignReceiver(t *testing.T) {
+	l := newLedger(t)
+	creator := l.account(types.RoleCreator)
+	other := l.account(types.RoleAdmin)
+	l.mustApply(types.TxTypeMint, creator, creator, 10, nil, 1)
+	if err := l.apply(types.TxTypeBurn, creator, other, 5, nil, 1); !errors.Is(err, ErrInvalidReceiver) {
+		t.Fatalf("burn: got %v, want ErrInvalidReceiver", err)
+	}
+	if err := l.apply(types.TxTypeSetPolicy, creator, other, 0, []byte(`{"max_supply":1}`), 1); !errors.Is(err, ErrInvalidReceiver) {
+		t.Fatalf("set policy: got %v, want ErrInvalidReceiver", err)
+	}
+}
@@ -0,0 +1,94 @@
+package audit
+
+import (
+	"distributed_ledger_go/pkg/crypto"
+	"distributed_ledger_go/pkg/types"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+const checkpointDomain = "ledger/audit-checkpoint"
+
+// 生成检查点的签名载荷：lp("ledger/audit-checkpoint") || index || lastHash || term || timestamp
+func CheckpointPayload(index uint64, lastHash [32]byte, term uint64, timestamp int64) []byte {
+	out := make([]byte, 0, 4+len(checkpointDomain)+8+32+8+8)
+	out = binary.BigEndian.AppendUint32(out, uint32(len(checkpointDomain)))
+	out = append(out, checkpointDomain...)
+	out = binary.BigEndian.AppendUint64(out, index)
+	out = append(out, lastHash[:]...)
+	out = binary.BigEndian.AppendUint64(out, term)
+	return binary.BigEndian.AppendUint64(out, uint64(timestamp))
+}
+
+// 解析检查点中的链头哈希
+func CheckpointHash(cp *types.Checkpoint) ([32]byte, error) {
+	var h [32]byte
+	b, err := hex.DecodeString(cp.LastHash)
+	if err != nil {
+		return h, fmt.Errorf("invalid last_hash: %v", err)
+	}
+	if len(b) != len(h) {
+		return h, errors.New("invalid last_hash: must be 32 bytes")
+	}
+	copy(h[:], b)
+	return h, nil
+}
+
+// 校验检查点签名，signer 为检查点中声明的节点身份地址
+func VerifyCheckpoint(cp *types.Checkpoint) error {
+	if cp == nil || cp.Index == 0 {
+		return errors.New("empty checkpoint")
+	}
+	lastHash, err := CheckpointHash(cp)
+	if err != nil {
+		return err
+	}
+	sig, err := hex.DecodeString(cp.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %v", err)
+	}
+	pub, err := crypto.HexToPublicKey(cp.Signer)
+	if err != nil {
+		return fmt.Errorf("invalid signer: %v", err)
+	}
+	if !crypto.VerifyASN1Signature(pub, CheckpointPayload(cp.Index, lastHash, cp.Term, cp.Timestamp), sig) {
+		return errors.New("checkpoint signature verification failed")
+	}
+	return nil
+}
+
+const reportDomain = "ledger/audit-report"
+
+// 生成副本检查点回报的签名载荷：lp("ledger/audit-report") || lp(nodeID) || index || lp(localHash) || timestamp
+func ReportPayload(nodeID string, index uint64, localHash string, timestamp int64) []byte {
+	out := make([]byte, 0, 4+len(reportDomain)+4+len(nodeID)+8+4+len(localHash)+8)
+	out = binary.BigEndian.AppendUint32(out, uint32(len(reportDomain)))
+	out = append(out, reportDomain...)
+	out = binary.BigEndian.AppendUint32(out, uint32(len(nodeID)))
+	out = append(out, nodeID...)
+	out = binary.BigEndian.AppendUint64(out, index)
+	out = binary.BigEndian.AppendUint32(out, uint32(len(localHash)))
+	out = append(out, localHash...)
+	return binary.BigEndian.AppendUint64(out, uint64(timestamp))
+}
+
+// 校验副本检查点回报的签名，signer 为回报中声明的节点身份地址
+func VerifyReport(r *types.CheckpointReport) error {
+	if r == nil || r.Index == 0 || r.NodeID == "" {
+		return errors.New("empty checkpoint report")
+	}
+	sig, err := hex.DecodeString(r.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %v", err)
+	}
+	pub, err := crypto.HexToPublicKey(r.Signer)
+	if err != nil {
+		return fmt.Errorf("invalid signer: %v", err)
+	}
+	if !crypto.VerifyASN1Signature(pub, ReportPayload(r.NodeID, r.Index, r.LocalHash, r.Timestamp), sig) {
+		return errors.New("checkpoint report signature verification failed")
+	}
+	return nil
+}
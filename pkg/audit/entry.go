@@ -2,11 +2,14 @@ package audit
 
 import (
 	"crypto/sha256"
-	"distributed_ledger_go/internal/types"
+	"distributed_ledger_go/pkg/types"
 	"encoding/binary"
 	"errors"
 )
 
+// 携带元数据的条目在 TxBytes 之后追加 timestamp、term、raftIndex（各 8 字节小端）
+const entryMetaLen = 8 * 3
+
 // 序列化Entry
 func EncodeEntry(e *types.Entry) ([]byte, error) {
 	if e == nil {
@@ -30,6 +33,11 @@ func EncodeEntry(e *types.Entry) ([]byte, error) {
 	out = append(out, b4[:]...)
 
 	out = append(out, e.TxBytes...)
+	if HasEntryMeta(PayloadVersion(e.TxBytes)) {
+		out = binary.LittleEndian.AppendUint64(out, uint64(e.Timestamp))
+		out = binary.LittleEndian.AppendUint64(out, e.Term)
+		out = binary.LittleEndian.AppendUint64(out, e.RaftIndex)
+	}
 	return out, nil
 }
 
@@ -46,16 +54,61 @@ func DecodeEntry(b []byte) (*types.Entry, error) {
 	copy(e.EntryHash[:], b[8+32:8+32+32])
 
 	txLen := binary.LittleEndian.Uint32(b[8+32+32 : 8+32+32+4])
-	if len(b) != header+int(txLen) {
+	if len(b) < header+int(txLen) {
 		return nil, errors.New("invalid entry bytes: length mismatch")
 	}
 	if txLen > 0 {
 		e.TxBytes = make([]byte, txLen)
-		copy(e.TxBytes, b[header:])
+		copy(e.TxBytes, b[header:header+int(txLen)])
 	}
+	e.Version = PayloadVersion(e.TxBytes)
+	meta := b[header+int(txLen):]
+	if !HasEntryMeta(e.Version) {
+		if len(meta) != 0 {
+			return nil, errors.New("invalid entry bytes: length mismatch")
+		}
+		return e, nil
+	}
+	if len(meta) != entryMetaLen {
+		return nil, errors.New("invalid entry bytes: length mismatch")
+	}
+	e.Timestamp = int64(binary.LittleEndian.Uint64(meta[:8]))
+	e.Term = binary.LittleEndian.Uint64(meta[8:16])
+	e.RaftIndex = binary.LittleEndian.Uint64(meta[16:])
 	return e, nil
 }
 
+// 计算条目哈希：携带元数据的条目使用 AuditHashWithMeta，其余沿用 AuditHash
+func EntryHash(e *types.Entry) [32]byte {
+	if HasEntryMeta(PayloadVersion(e.TxBytes)) {
+		return AuditHashWithMeta(e.Index, e.PrevHash, e.TxBytes, e.Timestamp, e.Term, e.RaftIndex)
+	}
+	return AuditHash(e.Index, e.PrevHash, e.TxBytes)
+}
+
+// 在 AuditHash 的输入之后追加 timestamp、term、raftIndex（各 8 字节小端）
+func AuditHashWithMeta(index uint64, prev [32]byte, txBytes []byte, timestamp int64, term, raftIndex uint64) [32]byte {
+	h := sha256.New()
+
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], index)
+	h.Write(buf[:])
+
+	h.Write(prev[:])
+
+	txHash := sha256.Sum256(txBytes)
+	h.Write(txHash[:])
+
+	for _, v := range []uint64{uint64(timestamp), term, raftIndex} {
+		binary.LittleEndian.PutUint64(buf[:], v)
+		h.Write(buf[:])
+	}
+
+	var out [32]byte
+	copy(out[:], h.Sum(nil))
+	return out
+}
+
 // 生成Entry的Hash
 func AuditHash(index uint64, prev [32]byte, txBytes []byte) [32]byte {
 	h := sha256.New()
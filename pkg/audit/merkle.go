@@ -0,0 +1,240 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"distributed_ledger_go/pkg/crypto"
+	"distributed_ledger_go/pkg/types"
+	"encoding/binary"
+	"errors"
+	"math/bits"
+)
+
+// Merkle 树采用与证书透明日志（RFC 6962）相同的构造：
+// 叶子为 SHA-256(0x00 || EntryHash)，内部节点为 SHA-256(0x01 || left || right)，
+// 第 i 个审计条目（索引从 1 开始）对应第 i-1 个叶子。
+
+const signedRootDomain = "ledger/audit-root"
+
+// NodeReader 读取已持久化的完美子树哈希：level 层、第 index 个节点，
+// 覆盖叶子区间 [index<<level, (index+1)<<level)。
+type NodeReader func(level uint8, index uint64) ([32]byte, error)
+
+// 计算叶子哈希
+func MerkleLeafHash(entryHash [32]byte) [32]byte {
+	var buf [33]byte
+	buf[0] = 0x00
+	copy(buf[1:], entryHash[:])
+	return sha256.Sum256(buf[:])
+}
+
+// 计算内部节点哈希
+func MerkleNodeHash(left, right [32]byte) [32]byte {
+	var buf [65]byte
+	buf[0] = 0x01
+	copy(buf[1:33], left[:])
+	copy(buf[33:], right[:])
+	return sha256.Sum256(buf[:])
+}
+
+// 计算叶子区间 [start, end) 的子树哈希（RFC 6962 MTH），完美对齐的子树直接读取存储节点
+func SubtreeRoot(read NodeReader, start, end uint64) ([32]byte, error) {
+	if end <= start {
+		return sha256.Sum256(nil), nil
+	}
+	size := end - start
+	if size&(size-1) == 0 && start%size == 0 {
+		level := uint8(bits.TrailingZeros64(size))
+		return read(level, start>>level)
+	}
+	k := largestPowerOfTwoBelow(size)
+	left, err := SubtreeRoot(read, start, start+k)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	right, err := SubtreeRoot(read, start+k, end)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return MerkleNodeHash(left, right), nil
+}
+
+// 生成第 leaf 个叶子（从 0 开始）在大小为 treeSize 的树中的包含性证明（RFC 6962 PATH）
+func InclusionProof(read NodeReader, leaf, treeSize uint64) ([][32]byte, error) {
+	if leaf >= treeSize {
+		return nil, errors.New("leaf index out of range")
+	}
+	return inclusionPath(read, leaf, 0, treeSize)
+}
+
+func inclusionPath(read NodeReader, leaf, start, end uint64) ([][32]byte, error) {
+	size := end - start
+	if size == 1 {
+		return nil, nil
+	}
+	k := largestPowerOfTwoBelow(size)
+	if leaf < start+k {
+		path, err := inclusionPath(read, leaf, start, start+k)
+		if err != nil {
+			return nil, err
+		}
+		sibling, err := SubtreeRoot(read, start+k, end)
+		if err != nil {
+			return nil, err
+		}
+		return append(path, sibling), nil
+	}
+	path, err := inclusionPath(read, leaf, start+k, end)
+	if err != nil {
+		return nil, err
+	}
+	sibling, err := SubtreeRoot(read, start, start+k)
+	if err != nil {
+		return nil, err
+	}
+	return append(path, sibling), nil
+}
+
+// 校验包含性证明（RFC 9162 2.1.3.2），leaf 从 0 开始
+func VerifyInclusion(leafHash [32]byte, leaf, treeSize uint64, proof [][32]byte, root [32]byte) bool {
+	if leaf >= treeSize {
+		return false
+	}
+	fn, sn := leaf, treeSize-1
+	r := leafHash
+	for _, p := range proof {
+		if sn == 0 {
+			return false
+		}
+		if fn&1 == 1 || fn == sn {
+			r = MerkleNodeHash(p, r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = MerkleNodeHash(r, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	return sn == 0 && r == root
+}
+
+// 生成从大小 oldSize 到 newSize 的一致性证明（RFC 6962 PROOF），证明新树是旧树的追加扩展
+func ConsistencyProof(read NodeReader, oldSize, newSize uint64) ([][32]byte, error) {
+	if oldSize == 0 || oldSize > newSize {
+		return nil, errors.New("invalid tree sizes for consistency proof")
+	}
+	return subProof(read, oldSize, 0, newSize, true)
+}
+
+func subProof(read NodeReader, m, start, end uint64, complete bool) ([][32]byte, error) {
+	size := end - start
+	if m == size {
+		if complete {
+			return nil, nil
+		}
+		h, err := SubtreeRoot(read, start, end)
+		if err != nil {
+			return nil, err
+		}
+		return [][32]byte{h}, nil
+	}
+	k := largestPowerOfTwoBelow(size)
+	if m <= k {
+		proof, err := subProof(read, m, start, start+k, complete)
+		if err != nil {
+			return nil, err
+		}
+		h, err := SubtreeRoot(read, start+k, end)
+		if err != nil {
+			return nil, err
+		}
+		return append(proof, h), nil
+	}
+	proof, err := subProof(read, m-k, start+k, end, false)
+	if err != nil {
+		return nil, err
+	}
+	h, err := SubtreeRoot(read, start, start+k)
+	if err != nil {
+		return nil, err
+	}
+	return append(proof, h), nil
+}
+
+// 校验一致性证明（RFC 9162 2.1.4.2）：大小为 newSize、根为 newRoot 的树
+// 是大小为 oldSize、根为 oldRoot 的树的追加扩展
+func VerifyConsistency(oldSize, newSize uint64, oldRoot, newRoot [32]byte, proof [][32]byte) bool {
+	if oldSize == 0 || oldSize > newSize {
+		return false
+	}
+	if oldSize == newSize {
+		return len(proof) == 0 && oldRoot == newRoot
+	}
+	if len(proof) == 0 {
+		return false
+	}
+	if oldSize&(oldSize-1) == 0 {
+		proof = append([][32]byte{oldRoot}, proof...)
+	}
+	fn, sn := oldSize-1, newSize-1
+	for fn&1 == 1 {
+		fn >>= 1
+		sn >>= 1
+	}
+	fr, sr := proof[0], proof[0]
+	for _, c := range proof[1:] {
+		if sn == 0 {
+			return false
+		}
+		if fn&1 == 1 || fn == sn {
+			fr = MerkleNodeHash(c, fr)
+			sr = MerkleNodeHash(c, sr)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			sr = MerkleNodeHash(sr, c)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	return fr == oldRoot && sr == newRoot && sn == 0
+}
+
+// 校验审计条目包含在大小为 treeSize、根为 root 的审计树中：
+// 先确认条目自身哈希正确，再校验 Merkle 路径
+func VerifyEntryInclusion(e *types.Entry, treeSize uint64, proof [][32]byte, root [32]byte) bool {
+	if e == nil || e.Index == 0 {
+		return false
+	}
+	if EntryHash(e) != e.EntryHash {
+		return false
+	}
+	return VerifyInclusion(MerkleLeafHash(e.EntryHash), e.Index-1, treeSize, proof, root)
+}
+
+// 生成审计树根的签名载荷：lp("ledger/audit-root") || uint64(treeSize) || root
+func SignedRootPayload(treeSize uint64, root [32]byte) []byte {
+	out := make([]byte, 0, 4+len(signedRootDomain)+8+32)
+	out = binary.BigEndian.AppendUint32(out, uint32(len(signedRootDomain)))
+	out = append(out, signedRootDomain...)
+	out = binary.BigEndian.AppendUint64(out, treeSize)
+	return append(out, root[:]...)
+}
+
+// 校验节点对审计树根的签名，signer 为节点身份公钥地址（hex）
+func VerifySignedRoot(signer string, treeSize uint64, root [32]byte, signature []byte) bool {
+	pub, err := crypto.HexToPublicKey(signer)
+	if err != nil {
+		return false
+	}
+	return crypto.VerifyASN1Signature(pub, SignedRootPayload(treeSize, root), signature)
+}
+
+// 返回严格小于 n 的最大 2 的幂（n > 1）
+func largestPowerOfTwoBelow(n uint64) uint64 {
+	return uint64(1) << (bits.Len64(n-1) - 1)
+}
@@ -0,0 +1,373 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+)
+
+// RFC 6962 参考实现（certificate-transparency）使用的 8 个叶子及各前缀树的根
+var rfcLeaves = []string{
+	"",
+	"00",
+	"10",
+	"2021",
+	"3031",
+	"40414243",
+	"5051525354555657",
+	"606162636465666768696a6b6c6d6e6f",
+}
+
+var rfcRoots = []string{
+	"6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d",
+	"fac54203e7cc696cf0dfcb42c92a1d9dbaf70ad9e621f4bd8d98662f00e3c125",
+	"aeb6bcfe274b70a14fb067a5e5578264db0fa9b51af5e0ba159158f329e06e77",
+	"d37ee418976dd95753c1c73862b9398fa2a2cf9b4ff0fdfe8b30cd95209614b7",
+	"4e3bbb1f7b478dcfe71fb631631519a3bca12c9aefca1612bfce4c13a86264d4",
+	"76e67dadbcdf1e10e1b74ddc608abd2f98dfb16fbce75277b5232a127f2087ef",
+	"ddb89be403809e325750d3d263cd78929c2942b7942a34b77e122c9594a74c8c",
+	"5dc9da79a70659a9ad559cb701ded9a2ab9d823aad2f4960cfe370eff4604328",
+}
+
+// rfcLeafHashes 按 RFC 6962 计算叶子哈希 SHA-256(0x00 || data)
+func rfcLeafHashes(t *testing.T) [][32]byte {
+	t.Helper()
+	out := make([][32]byte, len(rfcLeaves))
+	for i, s := range rfcLeaves {
+		data, err := hex.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out[i] = sha256.Sum256(append([]byte{0x00}, data...))
+	}
+	return out
+}
+
+// syntheticLeafHashes 生成 n 个互不相同的叶子哈希
+func syntheticLeafHashes(n int) [][32]byte {
+	out := make([][32]byte, n)
+	for i := range out {
+		out[i] = MerkleLeafHash(sha256.Sum256([]byte(fmt.Sprintf("entry-%d", i))))
+	}
+	return out
+}
+
+// memoryTree 以叶子哈希构造 NodeReader，模拟存储中的完美子树节点
+func memoryTree(leaves [][32]byte) NodeReader {
+	return func(level uint8, index uint64) ([32]byte, error) {
+		start := index << level
+		end := start + uint64(1)<<level
+		if end > uint64(len(leaves)) {
+			return [32]byte{}, fmt.Errorf("node (%d, %d) not stored", level, index)
+		}
+		return refRoot(leaves[start:end]), nil
+	}
+}
+
+// refRoot 直接按 RFC 6962 MTH 的递归定义计算根，作为对照
+func refRoot(leaves [][32]byte) [32]byte {
+	switch len(leaves) {
+	case 0:
+		return sha256.Sum256(nil)
+	case 1:
+		return leaves[0]
+	}
+	k := largestPowerOfTwoBelow(uint64(len(leaves)))
+	return MerkleNodeHash(refRoot(leaves[:k]), refRoot(leaves[k:]))
+}
+
+func mustHashes(t *testing.T, hexes ...string) [][32]byte {
+	t.Helper()
+	out := make([][32]byte, len(hexes))
+	for i, s := range hexes {
+		b, err := hex.DecodeString(s)
+		if err != nil || len(b) != 32 {
+			t.Fatalf("bad hash %q", s)
+		}
+		copy(out[i][:], b)
+	}
+	return out
+}
+
+func equalProofs(a, b [][32]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMerkleLeafAndNodeHash(t *testing.T) {
+	var entry [32]byte
+	for i := range entry {
+		entry[i] = byte(i)
+	}
+	if got, want := MerkleLeafHash(entry), sha256.Sum256(append([]byte{0x00}, entry[:]...)); got != want {
+		t.Fatalf("leaf hash = %x, want %x", got, want)
+	}
+	left, right := sha256.Sum256([]byte("l")), sha256.Sum256([]byte("r"))
+	buf := append(append([]byte{0x01}, left[:]...), right[:]...)
+	if got, want := MerkleNodeHash(left, right), sha256.Sum256(buf); got != want {
+		t.Fatalf("node hash = %x, want %x", got, want)
+	}
+}
+
+func TestSubtreeRootRFC6962(t *testing.T) {
+	leaves := rfcLeafHashes(t)
+	read := memoryTree(leaves)
+	for n := 1; n <= len(leaves); n++ {
+		root, err := SubtreeRoot(read, 0, uint64(n))
+		if err != nil {
+			t.Fatalf("size %d: %v", n, err)
+		}
+		if got := hex.EncodeToString(root[:]); got != rfcRoots[n-1] {
+			t.Errorf("size %d: root = %s, want %s", n, got, rfcRoots[n-1])
+		}
+	}
+}
+
+func TestInclusionProofRFC6962Vectors(t *testing.T) {
+	leaves := rfcLeafHashes(t)
+	read := memoryTree(leaves)
+	tests := []struct {
+		leaf, size uint64
+		proof      []string
+	}{
+		{0, 1, nil},
+		{0, 8, []string{
+			"96a296d224f285c67bee93c30f8a309157f0daa35dc5b87e410b78630a09cfc7",
+			"5f083f0a1a33ca076a95279832580db3e0ef4584bdff1f54c8a360f50de3031e",
+			"6b47aaf29ee3c2af9af889bc1fb9254dabd31177f16232dd6aab035ca39bf6e4",
+		}},
+		{5, 8, []string{
+			"bc1a0643b12e4d2d7c77918f44e0f4f79a838b6cf9ec5b5c283e1f4d88599e6b",
+			"ca854ea128ed050b41b35ffc1b87b8eb2bde461e9e3b5596ece6b9d5975a0ae0",
+			"d37ee418976dd95753c1c73862b9398fa2a2cf9b4ff0fdfe8b30cd95209614b7",
+		}},
+		{2, 3, []string{
+			"fac54203e7cc696cf0dfcb42c92a1d9dbaf70ad9e621f4bd8d98662f00e3c125",
+		}},
+		{1, 5, []string{
+			"6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d",
+			"5f083f0a1a33ca076a95279832580db3e0ef4584bdff1f54c8a360f50de3031e",
+			"bc1a0643b12e4d2d7c77918f44e0f4f79a838b6cf9ec5b5c283e1f4d88599e6b",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("leaf%d_size%d", tt.leaf, tt.size), func(t *testing.T) {
+			proof, err := InclusionProof(read, tt.leaf, tt.size)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := mustHashes(t, tt.proof...); !equalProofs(proof, want) {
+				t.Fatalf("proof = %x, want %x", proof, want)
+			}
+			root := mustHashes(t, rfcRoots[tt.size-1])[0]
+			if !VerifyInclusion(leaves[tt.leaf], tt.leaf, tt.size, proof, root) {
+				t.Fatal("valid proof rejected")
+			}
+		})
+	}
+}
+
+func TestInclusionProofAllSizes(t *testing.T) {
+	leaves := syntheticLeafHashes(33)
+	read := memoryTree(leaves)
+	for n := uint64(1); n <= uint64(len(leaves)); n++ {
+		root := refRoot(leaves[:n])
+		for i := uint64(0); i < n; i++ {
+			proof, err := InclusionProof(read, i, n)
+			if err != nil {
+				t.Fatalf("leaf %d size %d: %v", i, n, err)
+			}
+			if !VerifyInclusion(leaves[i], i, n, proof, root) {
+				t.Fatalf("leaf %d size %d: valid proof rejected", i, n)
+			}
+		}
+	}
+	if _, err := InclusionProof(read, 4, 4); err == nil {
+		t.Fatal("expected error for leaf outside the tree")
+	}
+}
+
+func TestVerifyInclusionRejectsTampering(t *testing.T) {
+	leaves := syntheticLeafHashes(13)
+	read := memoryTree(leaves)
+	const leaf, size = 6, 13
+	root := refRoot(leaves[:size])
+	proof, err := InclusionProof(read, leaf, size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyInclusion(leaves[leaf], leaf, size, proof, root) {
+		t.Fatal("valid proof rejected")
+	}
+
+	for i := range proof {
+		tampered := append([][32]byte(nil), proof...)
+		tampered[i][0] ^= 0x01
+		if VerifyInclusion(leaves[leaf], leaf, size, tampered, root) {
+			t.Errorf("proof with element %d flipped accepted", i)
+		}
+	}
+	tests := []struct {
+		name  string
+		hash  [32]byte
+		leaf  uint64
+		size  uint64
+		proof [][32]byte
+		root  [32]byte
+	}{
+		{"wrong leaf hash", leaves[leaf+1], leaf, size, proof, root},
+		{"wrong leaf index", leaves[leaf], leaf + 1, size, proof, root},
+		// 路径形状相同的大小无法仅凭证明区分，由签名的 (大小, 根) 绑定；这里取需要更多层的大小
+		{"wrong tree size", leaves[leaf], leaf, 17, proof, root},
+		{"leaf outside tree", leaves[leaf], size, size, proof, root},
+		{"truncated proof", leaves[leaf], leaf, size, proof[:len(proof)-1], root},
+		{"extra element", leaves[leaf], leaf, size, append(append([][32]byte(nil), proof...), root), root},
+		{"wrong root", leaves[leaf], leaf, size, proof, refRoot(leaves[:size-1])},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if VerifyInclusion(tt.hash, tt.leaf, tt.size, tt.proof, tt.root) {
+				t.Fatal("tampered proof accepted")
+			}
+		})
+	}
+}
+
+// refConsistency 按 RFC 6962 SUBPROOF 的递归定义生成一致性证明，作为对照
+func refConsistency(m int, leaves [][32]byte, complete bool) [][32]byte {
+	n := len(leaves)
+	if m == n {
+		if complete {
+			return nil
+		}
+		return [][32]byte{refRoot(leaves)}
+	}
+	k := int(largestPowerOfTwoBelow(uint64(n)))
+	if m <= k {
+		return append(refConsistency(m, leaves[:k], complete), refRoot(leaves[k:]))
+	}
+	return append(refConsistency(m-k, leaves[k:], false), refRoot(leaves[:k]))
+}
+
+func TestConsistencyProofRFC6962Vectors(t *testing.T) {
+	leaves := rfcLeafHashes(t)
+	read := memoryTree(leaves)
+	tests := []struct {
+		oldSize, newSize uint64
+		proof            []string
+	}{
+		{1, 1, nil},
+		{1, 8, []string{
+			"96a296d224f285c67bee93c30f8a309157f0daa35dc5b87e410b78630a09cfc7",
+			"5f083f0a1a33ca076a95279832580db3e0ef4584bdff1f54c8a360f50de3031e",
+			"6b47aaf29ee3c2af9af889bc1fb9254dabd31177f16232dd6aab035ca39bf6e4",
+		}},
+		{6, 8, []string{
+			"0ebc5d3437fbe2db158b9f126a1d118e308181031d0a949f8dededebc558ef6a",
+			"ca854ea128ed050b41b35ffc1b87b8eb2bde461e9e3b5596ece6b9d5975a0ae0",
+			"d37ee418976dd95753c1c73862b9398fa2a2cf9b4ff0fdfe8b30cd95209614b7",
+		}},
+		{2, 5, []string{
+			"5f083f0a1a33ca076a95279832580db3e0ef4584bdff1f54c8a360f50de3031e",
+			"bc1a0643b12e4d2d7c77918f44e0f4f79a838b6cf9ec5b5c283e1f4d88599e6b",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d_to_%d", tt.oldSize, tt.newSize), func(t *testing.T) {
+			proof, err := ConsistencyProof(read, tt.oldSize, tt.newSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := mustHashes(t, tt.proof...); !equalProofs(proof, want) {
+				t.Fatalf("proof = %x, want %x", proof, want)
+			}
+			oldRoot := mustHashes(t, rfcRoots[tt.oldSize-1])[0]
+			newRoot := mustHashes(t, rfcRoots[tt.newSize-1])[0]
+			if !VerifyConsistency(tt.oldSize, tt.newSize, oldRoot, newRoot, proof) {
+				t.Fatal("valid proof rejected")
+			}
+		})
+	}
+}
+
+func TestConsistencyProofAllSizes(t *testing.T) {
+	leaves := syntheticLeafHashes(33)
+	read := memoryTree(leaves)
+	for n := 1; n <= len(leaves); n++ {
+		newRoot := refRoot(leaves[:n])
+		for m := 1; m <= n; m++ {
+			proof, err := ConsistencyProof(read, uint64(m), uint64(n))
+			if err != nil {
+				t.Fatalf("%d to %d: %v", m, n, err)
+			}
+			if want := refConsistency(m, leaves[:n], true); !equalProofs(proof, want) {
+				t.Fatalf("%d to %d: proof differs from reference", m, n)
+			}
+			if !VerifyConsistency(uint64(m), uint64(n), refRoot(leaves[:m]), newRoot, proof) {
+				t.Fatalf("%d to %d: valid proof rejected", m, n)
+			}
+		}
+	}
+	for _, sizes := range [][2]uint64{{0, 4}, {5, 4}} {
+		if _, err := ConsistencyProof(read, sizes[0], sizes[1]); err == nil {
+			t.Errorf("%d to %d: expected error", sizes[0], sizes[1])
+		}
+	}
+}
+
+func TestVerifyConsistencyRejectsTampering(t *testing.T) {
+	leaves := syntheticLeafHashes(13)
+	read := memoryTree(leaves)
+	const oldSize, newSize = 6, 13
+	oldRoot, newRoot := refRoot(leaves[:oldSize]), refRoot(leaves[:newSize])
+	proof, err := ConsistencyProof(read, oldSize, newSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyConsistency(oldSize, newSize, oldRoot, newRoot, proof) {
+		t.Fatal("valid proof rejected")
+	}
+
+	for i := range proof {
+		tampered := append([][32]byte(nil), proof...)
+		tampered[i][0] ^= 0x01
+		if VerifyConsistency(oldSize, newSize, oldRoot, newRoot, tampered) {
+			t.Errorf("proof with element %d flipped accepted", i)
+		}
+	}
+	// 旧树中被改写的条目：新树根由篡改后的叶子计算
+	forked := append([][32]byte(nil), leaves[:newSize]...)
+	forked[2][0] ^= 0x01
+	tests := []struct {
+		name             string
+		oldSize, newSize uint64
+		oldRoot, newRoot [32]byte
+		proof            [][32]byte
+	}{
+		{"wrong old root", oldSize, newSize, refRoot(leaves[:oldSize-1]), newRoot, proof},
+		{"wrong new root", oldSize, newSize, oldRoot, refRoot(leaves[:newSize-1]), proof},
+		{"rewritten history", oldSize, newSize, oldRoot, refRoot(forked), proof},
+		{"wrong old size", oldSize + 1, newSize, oldRoot, newRoot, proof},
+		{"old larger than new", newSize + 1, newSize, oldRoot, newRoot, proof},
+		{"zero old size", 0, newSize, oldRoot, newRoot, proof},
+		{"truncated proof", oldSize, newSize, oldRoot, newRoot, proof[:len(proof)-1]},
+		{"empty proof", oldSize, newSize, oldRoot, newRoot, nil},
+		{"extra element", oldSize, newSize, oldRoot, newRoot, append(append([][32]byte(nil), proof...), newRoot)},
+		{"same size, different roots", oldSize, oldSize, oldRoot, newRoot, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if VerifyConsistency(tt.oldSize, tt.newSize, tt.oldRoot, tt.newRoot, tt.proof) {
+				t.Fatal("tampered proof accepted")
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package audit
+
+import (
+	"distributed_ledger_go/pkg/types"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// 审计载荷的编码版本，写在载荷首字节并随 TxBytes 一起计入 AuditHash；版本 0 为 JSON，见 DecodeTransaction。
+const (
+	PayloadVersionJSON byte = 0
+	PayloadVersionV1   byte = 1
+	// 版本 2 的交易编码与版本 1 相同，但条目额外携带时间戳、Raft 任期与索引，并计入条目哈希
+	PayloadVersionV2 byte = 2
+	// 版本 3 在版本 2 的基础上于签名前追加 lp(data)，用于携带额度策略等附加数据
+	PayloadVersionV3 byte = 3
+
+	// 新写入条目使用的编码版本
+	CurrentPayloadVersion = PayloadVersionV3
+)
+
+// 该版本的条目是否携带时间戳、任期与 Raft 索引
+func HasEntryMeta(version byte) bool {
+	return version >= PayloadVersionV2
+}
+
+// 返回审计载荷的编码版本
+func PayloadVersion(payload []byte) byte {
+	if len(payload) == 0 || payload[0] == '{' {
+		return PayloadVersionJSON
+	}
+	return payload[0]
+}
+
+// 按指定版本编码交易。版本由写入 Raft 日志的命令决定，重放旧日志时仍得到相同载荷
+func EncodePayload(version byte, tx types.Transaction) ([]byte, error) {
+	switch version {
+	case PayloadVersionJSON:
+		return json.Marshal(tx)
+	case PayloadVersionV1, PayloadVersionV2:
+		if len(tx.Data) > 0 {
+			return nil, fmt.Errorf("audit payload version %d cannot carry transaction data", version)
+		}
+		return encodeBinary(version, tx), nil
+	case PayloadVersionV3:
+		return encodeBinary(version, tx), nil
+	default:
+		return nil, fmt.Errorf("unsupported audit payload version %d", version)
+	}
+}
+
+// 按当前版本编码交易
+func EncodeTransaction(tx types.Transaction) []byte {
+	return encodeBinary(CurrentPayloadVersion, tx)
+}
+
+// 二进制编码（版本 1、2、3）：
+// version || lp(chainID) || int32(type) || lp(sender) || lp(receiver) ||
+// uint64(amount) || uint64(nonce) || int64(validUntil) || [lp(data)，仅版本 3] || lp(signature)，整数均为大端
+func encodeBinary(version byte, tx types.Transaction) []byte {
+	out := make([]byte, 0, 1+4*5+len(tx.ChainID)+len(tx.Sender)+len(tx.Receiver)+len(tx.Data)+len(tx.Signature)+4+8*3)
+	out = append(out, version)
+	out = appendLP(out, []byte(tx.ChainID))
+	out = binary.BigEndian.AppendUint32(out, uint32(int32(tx.Type)))
+	out = appendLP(out, []byte(tx.Sender))
+	out = appendLP(out, []byte(tx.Receiver))
+	out = binary.BigEndian.AppendUint64(out, tx.Amount)
+	out = binary.BigEndian.AppendUint64(out, tx.Nonce)
+	out = binary.BigEndian.AppendUint64(out, uint64(tx.ValidUntil))
+	if version >= PayloadVersionV3 {
+		out = appendLP(out, tx.Data)
+	}
+	return appendLP(out, tx.Signature)
+}
+
+// 解码任意版本的审计载荷。升级前的条目为 json.Marshal(tx)，首字节恒为 '{'，视为版本 0 按原格式解析；
+// types.Transaction 之后新增的字段均带 omitempty，重放旧日志时重新编码仍得到当时的字节，条目哈希不变
+func DecodeTransaction(payload []byte) (types.Transaction, error) {
+	var tx types.Transaction
+	switch v := PayloadVersion(payload); v {
+	case PayloadVersionJSON:
+		err := json.Unmarshal(payload, &tx)
+		return tx, err
+	case PayloadVersionV1, PayloadVersionV2, PayloadVersionV3:
+		r := payloadReader{buf: payload[1:]}
+		tx.ChainID = string(r.lp())
+		tx.Type = types.TxType(int32(r.u32()))
+		tx.Sender = string(r.lp())
+		tx.Receiver = string(r.lp())
+		tx.Amount = r.u64()
+		tx.Nonce = r.u64()
+		tx.ValidUntil = int64(r.u64())
+		if v >= PayloadVersionV3 {
+			if data := r.lp(); len(data) > 0 {
+				tx.Data = append([]byte(nil), data...)
+			}
+		}
+		if sig := r.lp(); len(sig) > 0 {
+			tx.Signature = append([]byte(nil), sig...)
+		}
+		if r.err != nil {
+			return types.Transaction{}, r.err
+		}
+		if len(r.buf) != 0 {
+			return types.Transaction{}, errors.New("invalid audit payload: trailing bytes")
+		}
+		return tx, nil
+	default:
+		return tx, fmt.Errorf("unsupported audit payload version %d", v)
+	}
+}
+
+func appendLP(out, b []byte) []byte {
+	out = binary.BigEndian.AppendUint32(out, uint32(len(b)))
+	return append(out, b...)
+}
+
+// payloadReader 顺序读取二进制载荷，遇到截断时记录首个错误
+type payloadReader struct {
+	buf []byte
+	err error
+}
+
+func (r *payloadReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if len(r.buf) < n {
+		r.err = errors.New("invalid audit payload: truncated")
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *payloadReader) u32() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *payloadReader) u64() uint64 {
+	if b := r.next(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (r *payloadReader) lp() []byte {
+	n := r.u32()
+	return r.next(int(n))
+}
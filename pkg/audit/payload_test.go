@@ -0,0 +1,155 @@
+package audit
+
+import (
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"distributed_ledger_go/pkg/types"
+)
+
+func sampleTransfer() types.Transaction {
+	return types.Transaction{
+		ChainID:    "ledger-dev",
+		Type:       types.TxTypeTransfer,
+		Sender:     "alice",
+		Receiver:   "bob",
+		Amount:     42,
+		Nonce:      7,
+		ValidUntil: 1700000000,
+		Signature:  []byte{0xde, 0xad, 0xbe, 0xef},
+	}
+}
+
+func samplePolicy() types.Transaction {
+	return types.Transaction{
+		ChainID:   "ledger-dev",
+		Type:      types.TxTypeSetPolicy,
+		Sender:    "alice",
+		Receiver:  "alice",
+		Nonce:     8,
+		Data:      []byte(`{"max_supply":1000000}`),
+		Signature: []byte{0xde, 0xad, 0xbe, 0xef},
+	}
+}
+
+func TestPayloadRoundTrip(t *testing.T) {
+	unsigned := sampleTransfer()
+	unsigned.Signature = nil
+	tests := []struct {
+		version byte
+		tx      types.Transaction
+	}{
+		{PayloadVersionJSON, sampleTransfer()},
+		{PayloadVersionJSON, samplePolicy()},
+		{PayloadVersionV1, sampleTransfer()},
+		{PayloadVersionV1, unsigned},
+		{PayloadVersionV2, sampleTransfer()},
+		{PayloadVersionV3, sampleTransfer()},
+		{PayloadVersionV3, samplePolicy()},
+		{PayloadVersionV3, unsigned},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("v%d_type%d", tt.version, tt.tx.Type), func(t *testing.T) {
+			payload, err := EncodePayload(tt.version, tt.tx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := PayloadVersion(payload); got != tt.version {
+				t.Fatalf("PayloadVersion = %d, want %d", got, tt.version)
+			}
+			got, err := DecodeTransaction(payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.tx) {
+				t.Fatalf("decoded %+v, want %+v", got, tt.tx)
+			}
+		})
+	}
+}
+
+func TestEncodeTransactionUsesCurrentVersion(t *testing.T) {
+	want, err := EncodePayload(CurrentPayloadVersion, samplePolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := EncodeTransaction(samplePolicy()); !reflect.DeepEqual(got, want) {
+		t.Fatalf("EncodeTransaction = %x, want %x", got, want)
+	}
+}
+
+// 二进制编码一旦写入审计链就不能再变，固定字节作为回归基线
+func TestPayloadGoldenEncoding(t *testing.T) {
+	tests := []struct {
+		version byte
+		tx      types.Transaction
+		want    string
+	}{
+		{PayloadVersionV1, sampleTransfer(),
+			"01" + "0000000a6c65646765722d646576" + "00000001" + "00000005616c696365" + "00000003626f62" +
+				"000000000000002a" + "0000000000000007" + "000000006553f100" + "00000004deadbeef"},
+		{PayloadVersionV3, samplePolicy(),
+			"03" + "0000000a6c65646765722d646576" + "00000005" + "00000005616c696365" + "00000005616c696365" +
+				"0000000000000000" + "0000000000000008" + "0000000000000000" +
+				"000000167b226d61785f737570706c79223a313030303030307d" + "00000004deadbeef"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("v%d", tt.version), func(t *testing.T) {
+			payload, err := EncodePayload(tt.version, tt.tx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hex.EncodeToString(payload); got != tt.want {
+				t.Fatalf("payload = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// 升级前的 JSON 载荷没有 ChainID、ValidUntil 与 Data 字段，重放时重新编码必须得到相同字节，否则条目哈希会变
+func TestLegacyJSONPayloadUnchanged(t *testing.T) {
+	tx := sampleTransfer()
+	tx.ChainID = ""
+	tx.ValidUntil = 0
+	payload, err := EncodePayload(PayloadVersionJSON, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"Type":1,"Sender":"alice","Receiver":"bob","Amount":42,"Nonce":7,"Signature":"3q2+7w=="}`
+	if string(payload) != want {
+		t.Fatalf("payload = %s, want %s", payload, want)
+	}
+}
+
+func TestEncodePayloadRejects(t *testing.T) {
+	for _, v := range []byte{PayloadVersionV1, PayloadVersionV2} {
+		if _, err := EncodePayload(v, samplePolicy()); err == nil {
+			t.Errorf("version %d: expected error for transaction data", v)
+		}
+	}
+	if _, err := EncodePayload(CurrentPayloadVersion+1, sampleTransfer()); err == nil {
+		t.Error("expected error for unsupported version")
+	}
+}
+
+func TestDecodeTransactionRejectsMalformed(t *testing.T) {
+	payload := EncodeTransaction(sampleTransfer())
+	tests := []struct {
+		name    string
+		payload []byte
+	}{
+		{"truncated", payload[:len(payload)-1]},
+		{"trailing bytes", append(append([]byte(nil), payload...), 0x00)},
+		{"unknown version", append([]byte{CurrentPayloadVersion + 1}, payload[1:]...)},
+		{"oversized length prefix", []byte{PayloadVersionV1, 0xff, 0xff, 0xff, 0xff}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeTransaction(tt.payload); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,1083 @@
+package client
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"distributed_ledger_go/pkg/audit"
+	"distributed_ledger_go/pkg/crypto"
+	"distributed_ledger_go/pkg/txhash"
+	"distributed_ledger_go/pkg/types"
+)
+
+// 对外暴露的账本类型别名，方便 SDK 使用者直接引用。
+type (
+	Account         = types.Account
+	AuditDivergence = types.AuditDivergence
+	Checkpoint      = types.Checkpoint
+	Entry           = types.Entry
+	History         = types.HistoryRecord
+	Limits          = types.Limits
+	Policy          = types.Policy
+	Receipt         = types.Receipt
+	Transaction     = types.Transaction
+	TxType          = types.TxType
+	Usage           = types.Usage
+)
+
+const (
+	TxTypeMint      = types.TxTypeMint
+	TxTypeTransfer  = types.TxTypeTransfer
+	TxTypeFreeze    = types.TxTypeFreeze
+	TxTypeUnfreeze  = types.TxTypeUnfreeze
+	TxTypeBurn      = types.TxTypeBurn
+	TxTypeSetPolicy = types.TxTypeSetPolicy
+)
+
+// APIError 表示服务端返回的非 2xx 响应。
+type APIError struct {
+	StatusCode int
+	// Code 为服务端返回的稳定错误码（如 CodeInsufficientBalance），旧版本节点或请求格式错误时为空
+	Code    string
+	Message string
+	Leader  string
+	// Receipt 为交易已上链但执行失败时服务端返回的失败回执
+	Receipt *Receipt
+}
+
+// ErrTxFailed 表示重试时按哈希取回的前一次提交已上链但执行失败，SubmitSigned 会同时返回该失败回执。
+var ErrTxFailed = errors.New("transaction failed")
+
+func (e *APIError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("ledger api: status %d (%s): %s", e.StatusCode, e.Code, e.Message)
+	}
+	return fmt.Sprintf("ledger api: status %d: %s", e.StatusCode, e.Message)
+}
+
+// 服务端错误码，与节点 internal/api 中的取值一致。
+const (
+	CodeInsufficientBalance = "insufficient_balance"
+	CodeNonceMismatch       = "nonce_mismatch"
+	CodeAccountExists       = "account_exists"
+	CodeAccountFrozen       = "account_frozen"
+	CodePermissionDenied    = "permission_denied"
+	CodeAccountNotFound     = "account_not_found"
+	CodeInvalidSignature    = "invalid_signature"
+	CodeChainMismatch       = "chain_mismatch"
+	CodeTxExpired           = "tx_expired"
+	CodeInvalidAmount       = "invalid_amount"
+	CodeUnknownTxType       = "unknown_tx_type"
+	CodeNotLeader           = "not_leader"
+	CodeTimeout             = "timeout"
+	CodeAuditDiverged       = "audit_diverged"
+	CodeGenesisMismatch     = "genesis_mismatch"
+	CodeInvalidReceiver     = "invalid_receiver"
+	CodeLimitExceeded       = "limit_exceeded"
+	CodeInvalidPolicy       = "invalid_policy"
+	CodeUnexpectedData      = "unexpected_data"
+	CodeInvalidReport       = "invalid_report"
+	CodeReceiptNotFound     = "receipt_not_found"
+	CodeEntryNotFound       = "entry_not_found"
+	CodeOutOfRange          = "out_of_range"
+	CodeInvalidRequest      = "invalid_request"
+	CodeUnauthenticated     = "unauthenticated"
+	CodeUnavailable         = "unavailable"
+	CodeVerifyRunning       = "audit_verify_running"
+	CodeSupplyMismatch      = "supply_mismatch"
+	CodeInternal            = "internal_error"
+)
+
+// ErrorCode 返回 err 中携带的服务端错误码，不是 APIError 时返回空字符串。
+func ErrorCode(err error) string {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return ""
+}
+
+// Client 封装账本 HTTP 接口，负责 leader 重定向、重试与 nonce 维护。
+type Client struct {
+	HTTPClient   *http.Client
+	Keystore     *Keystore
+	MaxRetries   int
+	RetryBackoff time.Duration
+	// ChainID 为空时，首次签名前从节点 /raft/status 读取
+	ChainID string
+	// TxValidity 大于 0 时，为签名交易设置 ValidUntil = 当前时间 + TxValidity
+	TxValidity time.Duration
+	// ReadConsistency 为账户查询的读一致性级别：linearizable、leader 或 stale，为空时使用节点默认（stale）
+	ReadConsistency string
+
+	mu        sync.Mutex
+	endpoints []string
+	current   int
+	// nonces 记录每个发送方最后一个已预留的 nonce
+	nonces map[string]uint64
+	// senders 串行化同一发送方的签名与提交，保证交易按 nonce 顺序到达 leader
+	senders map[string]*sync.Mutex
+}
+
+// New 使用一个或多个节点地址（如 127.0.0.1:8101 或 http://host:port）创建客户端。
+func New(endpoints ...string) *Client {
+	eps := make([]string, 0, len(endpoints))
+	for _, ep := range endpoints {
+		eps = append(eps, normalizeEndpoint(ep))
+	}
+	return &Client{
+		HTTPClient:   &http.Client{Timeout: 10 * time.Second},
+		MaxRetries:   3,
+		RetryBackoff: 200 * time.Millisecond,
+		endpoints:    eps,
+		nonces:       map[string]uint64{},
+		senders:      map[string]*sync.Mutex{},
+	}
+}
+
+// RegisterResult 为注册接口的返回值。
+type RegisterResult struct {
+	Address    string `json:"address"`
+	PrivateKey string `json:"private_key"`
+	Role       string `json:"role"`
+}
+
+// RoleResult 为升降级接口的返回值。
+type RoleResult struct {
+	Target string `json:"target"`
+	Role   string `json:"role"`
+}
+
+// TransactionRecord 为流水查询中的单条记录。
+type TransactionRecord struct {
+	Index    uint64 `json:"index"`
+	Type     TxType `json:"type"`
+	Sender   string `json:"sender"`
+	Receiver string `json:"receiver"`
+	Amount   uint64 `json:"amount"`
+	Nonce    uint64 `json:"nonce"`
+}
+
+// QueryResult 为流水查询的返回值。
+type QueryResult struct {
+	Transactions []TransactionRecord `json:"transactions"`
+	NextCursor   uint64              `json:"next_cursor"`
+	TotalMinted  uint64              `json:"total_minted,omitempty"`
+	TotalSupply  uint64              `json:"total_supply,omitempty"`
+}
+
+// Register 注册新账户；若配置了 Keystore，会自动保存返回的私钥。
+func (c *Client) Register() (*RegisterResult, error) {
+	var res RegisterResult
+	if err := c.do(http.MethodPost, "/accounts/register", nil, &res); err != nil {
+		return nil, err
+	}
+	if c.Keystore != nil && res.PrivateKey != "" {
+		if _, err := c.Keystore.Import(res.PrivateKey); err != nil {
+			return &res, err
+		}
+	}
+	return &res, nil
+}
+
+// GetAccount 查询账户详情。
+func (c *Client) GetAccount(address string) (*Account, error) {
+	path := "/accounts/" + address
+	if c.ReadConsistency != "" {
+		path += "?consistency=" + url.QueryEscape(c.ReadConsistency)
+	}
+	var acc Account
+	if err := c.do(http.MethodGet, path, nil, &acc); err != nil {
+		return nil, err
+	}
+	return &acc, nil
+}
+
+// Promote 由创世者将目标账户提升为管理员。
+func (c *Client) Promote(creator, target string) (*RoleResult, error) {
+	return c.changeRole("/accounts/promote", types.RoleAdmin, creator, target)
+}
+
+// Demote 由创世者将目标账户降级为普通用户。
+func (c *Client) Demote(creator, target string) (*RoleResult, error) {
+	return c.changeRole("/accounts/demote", types.RoleUser, creator, target)
+}
+
+// changeRole 使用创世者的下一个 nonce 在本地对角色变更签名，私钥不离开本机。
+func (c *Client) changeRole(path, role, creator, target string) (*RoleResult, error) {
+	if c.Keystore == nil {
+		return nil, errors.New("keystore not configured")
+	}
+	priv, err := c.Keystore.Get(creator)
+	if err != nil {
+		return nil, err
+	}
+	chainID, err := c.chainID()
+	if err != nil {
+		return nil, err
+	}
+	unlock := c.lockSender(creator)
+	defer unlock()
+	nonce, err := c.nextNonce(creator)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := crypto.Sign(priv, txhash.RoleHash(chainID, creator, target, role, nonce))
+	if err != nil {
+		c.releaseNonce(creator, nonce, err)
+		return nil, err
+	}
+	body := map[string]interface{}{
+		"creator_address": creator,
+		"target_address":  target,
+		"nonce":           nonce,
+		"signature":       hex.EncodeToString(sig),
+	}
+	var res RoleResult
+	if err := c.do(http.MethodPost, path, body, &res); err != nil {
+		c.releaseNonce(creator, nonce, err)
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Mint 铸币给管理员账户。
+func (c *Client) Mint(sender, receiver string, amount uint64) (*Receipt, error) {
+	return c.SendTransaction(TxTypeMint, sender, receiver, amount)
+}
+
+// Transfer 点对点转账。
+func (c *Client) Transfer(sender, receiver string, amount uint64) (*Receipt, error) {
+	return c.SendTransaction(TxTypeTransfer, sender, receiver, amount)
+}
+
+// Freeze 冻结目标账户。
+func (c *Client) Freeze(sender, target string) (*Receipt, error) {
+	return c.SendTransaction(TxTypeFreeze, sender, target, 0)
+}
+
+// Burn 销毁发送方自己的 amount 余额，用于管理员链下兑付后回收代币。
+func (c *Client) Burn(sender string, amount uint64) (*Receipt, error) {
+	return c.SendTransaction(TxTypeBurn, sender, sender, amount)
+}
+
+// Unfreeze 解冻目标账户。
+func (c *Client) Unfreeze(sender, target string) (*Receipt, error) {
+	return c.SendTransaction(TxTypeUnfreeze, sender, target, 0)
+}
+
+// SetPolicy 由创世者设置额度策略，新策略整体替换旧策略。
+func (c *Client) SetPolicy(sender string, policy *Policy) (*Receipt, error) {
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return nil, err
+	}
+	return c.send(Transaction{Type: TxTypeSetPolicy, Sender: sender, Receiver: sender, Data: data})
+}
+
+// SendTransaction 自动分配 nonce，本地签名后通过 /transactions/submit 提交。
+func (c *Client) SendTransaction(txType TxType, sender, receiver string, amount uint64) (*Receipt, error) {
+	return c.send(Transaction{Type: txType, Sender: sender, Receiver: receiver, Amount: amount})
+}
+
+// send 为交易补全 chain id 与 nonce，签名后提交。
+func (c *Client) send(tx Transaction) (*Receipt, error) {
+	chainID, err := c.chainID()
+	if err != nil {
+		return nil, err
+	}
+	unlock := c.lockSender(tx.Sender)
+	defer unlock()
+	nonce, err := c.nextNonce(tx.Sender)
+	if err != nil {
+		return nil, err
+	}
+	tx.ChainID = chainID
+	tx.Nonce = nonce
+	if c.TxValidity > 0 {
+		tx.ValidUntil = time.Now().Add(c.TxValidity).Unix()
+	}
+	if err := c.Sign(&tx); err != nil {
+		c.releaseNonce(tx.Sender, nonce, err)
+		return nil, err
+	}
+	receipt, err := c.SubmitSigned(tx)
+	if err != nil {
+		c.releaseNonce(tx.Sender, nonce, err)
+		return receipt, err
+	}
+	return receipt, nil
+}
+
+// Sign 使用 Keystore 中发送方的私钥对交易签名。
+func (c *Client) Sign(tx *Transaction) error {
+	if c.Keystore == nil {
+		return errors.New("keystore not configured")
+	}
+	priv, err := c.Keystore.Get(tx.Sender)
+	if err != nil {
+		return err
+	}
+	sig, err := crypto.Sign(priv, txhash.TxHash(*tx))
+	if err != nil {
+		return err
+	}
+	tx.Signature = sig
+	return nil
+}
+
+// SubmitSigned 提交已签名交易并返回回执。交易已上链但执行失败时，同时返回失败回执与错误。
+func (c *Client) SubmitSigned(tx Transaction) (*Receipt, error) {
+	body := map[string]interface{}{
+		"chain_id":    tx.ChainID,
+		"type":        tx.Type,
+		"sender":      tx.Sender,
+		"receiver":    tx.Receiver,
+		"amount":      tx.Amount,
+		"nonce":       tx.Nonce,
+		"valid_until": tx.ValidUntil,
+		"signature":   hex.EncodeToString(tx.Signature),
+	}
+	if len(tx.Data) > 0 {
+		body["data"] = hex.EncodeToString(tx.Data)
+	}
+	var res struct {
+		Receipt *Receipt `json:"receipt"`
+	}
+	if err := c.do(http.MethodPost, "/transactions/submit", body, &res); err != nil {
+		// 重试时前一次提交可能已经上链，此时按哈希取回它的回执，无论成功与否
+		if ErrorCode(err) == CodeNonceMismatch {
+			if r, rerr := c.GetReceipt(hex.EncodeToString(txhash.TxHash(tx))); rerr == nil {
+				if r.Status != types.ReceiptStatusSuccess {
+					return r, fmt.Errorf("%w: %s", ErrTxFailed, r.Error)
+				}
+				return r, nil
+			}
+		}
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			return apiErr.Receipt, err
+		}
+		return nil, err
+	}
+	return res.Receipt, nil
+}
+
+// GetReceipt 按交易哈希（hex）查询回执。
+func (c *Client) GetReceipt(txHash string) (*Receipt, error) {
+	var r Receipt
+	if err := c.do(http.MethodGet, "/transactions/"+txHash, nil, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// QueryTransactions 以签名方式查询请求者可见的流水。
+func (c *Client) QueryTransactions(requester string) (*QueryResult, error) {
+	const path = "/transactions/query"
+	ts, nonce, sig, err := c.signRequest(requester, http.MethodPost, path)
+	if err != nil {
+		return nil, err
+	}
+	body := map[string]interface{}{
+		"requester_address": requester,
+		"timestamp":         ts,
+		"nonce":             nonce,
+		"signature":         sig,
+	}
+	var res QueryResult
+	if err := c.do(http.MethodPost, path, body, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// HistoryOptions 为账户流水分页查询条件，零值字段表示不过滤。
+type HistoryOptions struct {
+	Type         *TxType
+	Counterparty string
+	FromIndex    uint64
+	ToIndex      uint64
+	// FromTime、ToTime 为交易时间范围（Unix 秒，闭区间）
+	FromTime int64
+	ToTime   int64
+	Cursor   uint64
+	Limit    int
+}
+
+// HistoryPage 为一页账户流水，NextCursor 为 0 表示没有更多数据。
+type HistoryPage struct {
+	Transactions []History `json:"transactions"`
+	NextCursor   uint64    `json:"next_cursor"`
+}
+
+// AccountHistory 以 requester 的身份签名查询 address 的流水。
+func (c *Client) AccountHistory(requester, address string, opts HistoryOptions) (*HistoryPage, error) {
+	q := url.Values{}
+	if opts.Type != nil {
+		q.Set("type", strconv.Itoa(int(*opts.Type)))
+	}
+	if opts.Counterparty != "" {
+		q.Set("counterparty", opts.Counterparty)
+	}
+	setUint := func(name string, v uint64) {
+		if v != 0 {
+			q.Set(name, strconv.FormatUint(v, 10))
+		}
+	}
+	setUint("from_index", opts.FromIndex)
+	setUint("to_index", opts.ToIndex)
+	setInt := func(name string, v int64) {
+		if v > 0 {
+			q.Set(name, strconv.FormatInt(v, 10))
+		}
+	}
+	setInt("from_time", opts.FromTime)
+	setInt("to_time", opts.ToTime)
+	setUint("cursor", opts.Cursor)
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if c.ReadConsistency != "" {
+		q.Set("consistency", c.ReadConsistency)
+	}
+	path := "/accounts/" + address + "/transactions"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	// 签名覆盖查询参数，截获的请求无法改写过滤条件
+	headers, err := c.requestHeaders(requester, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+	var page HistoryPage
+	if err := c.doWithHeaders(http.MethodGet, path, headers, nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// requestHeaders 生成签名请求（流水查询与运维接口）所需的请求头，签名绑定方法、路径与随机 nonce，每次调用都不同。
+func (c *Client) requestHeaders(requester, method, path string) (map[string]string, error) {
+	ts, nonce, sig, err := c.signRequest(requester, method, path)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"X-Requester-Address": requester,
+		"X-Timestamp":         strconv.FormatInt(ts, 10),
+		"X-Nonce":             nonce,
+		"X-Signature":         sig,
+	}, nil
+}
+
+// signRequest 以 requester 的私钥对 txhash.RequestHash 签名，返回时间戳、随机 nonce 与 hex 签名。
+func (c *Client) signRequest(requester, method, path string) (int64, string, string, error) {
+	if c.Keystore == nil {
+		return 0, "", "", errors.New("keystore not configured")
+	}
+	priv, err := c.Keystore.Get(requester)
+	if err != nil {
+		return 0, "", "", err
+	}
+	chainID, err := c.chainID()
+	if err != nil {
+		return 0, "", "", err
+	}
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return 0, "", "", err
+	}
+	nonce := hex.EncodeToString(buf[:])
+	ts := time.Now().Unix()
+	sig, err := crypto.Sign(priv, txhash.RequestHash(chainID, requester, method, path, ts, nonce))
+	if err != nil {
+		return 0, "", "", err
+	}
+	return ts, nonce, hex.EncodeToString(sig), nil
+}
+
+// AuditEntry 按索引读取审计条目。
+func (c *Client) AuditEntry(index uint64) (*Entry, error) {
+	var e Entry
+	if err := c.do(http.MethodGet, fmt.Sprintf("/audit/%d", index), nil, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// AuditPage 为一页审计条目，NextFrom 为下一页起始索引，0 表示已到链头。
+type AuditPage struct {
+	Entries   []Entry `json:"entries"`
+	NextFrom  uint64  `json:"next_from"`
+	LastIndex uint64  `json:"last_index"`
+}
+
+// AuditHead 为审计链头部信息。
+type AuditHead struct {
+	LastIndex uint64 `json:"last_index"`
+	LastHash  string `json:"last_hash"`
+}
+
+// AuditEntries 从 from 开始分页读取审计条目。
+func (c *Client) AuditEntries(from uint64, limit int) (*AuditPage, error) {
+	var page AuditPage
+	path := fmt.Sprintf("/audit?from=%d&limit=%d", from, limit)
+	if err := c.do(http.MethodGet, path, nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// AuditEntriesByTime 分页读取时间在 [fromTime, toTime]（Unix 秒）内的审计条目，
+// toTime 为 0 表示不限上界；翻页时把上一页的 NextFrom 作为 from 传入。
+func (c *Client) AuditEntriesByTime(fromTime, toTime int64, from uint64, limit int) (*AuditPage, error) {
+	var page AuditPage
+	path := fmt.Sprintf("/audit?from=%d&limit=%d&from_time=%d", from, limit, fromTime)
+	if toTime != 0 {
+		path += fmt.Sprintf("&to_time=%d", toTime)
+	}
+	if err := c.do(http.MethodGet, path, nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// AuditHead 读取审计链最新索引与哈希。
+func (c *Client) AuditHead() (*AuditHead, error) {
+	var head AuditHead
+	if err := c.do(http.MethodGet, "/audit/head", nil, &head); err != nil {
+		return nil, err
+	}
+	return &head, nil
+}
+
+// CheckpointPage 为检查点分页结果，NextFrom 为 0 表示没有更多检查点。
+type CheckpointPage struct {
+	Checkpoints []Checkpoint `json:"checkpoints"`
+	NextFrom    uint64       `json:"next_from"`
+}
+
+// Checkpoints 从审计索引 from 开始分页读取 leader 签名的检查点。
+func (c *Client) Checkpoints(from uint64, limit int) (*CheckpointPage, error) {
+	var page CheckpointPage
+	path := fmt.Sprintf("/audit/checkpoints?from=%d&limit=%d", from, limit)
+	if err := c.do(http.MethodGet, path, nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// ExportAudit 以 NDJSON 流式导出从 from 开始的审计条目并写入 w，不做重试。
+func (c *Client) ExportAudit(from uint64, w io.Writer) error {
+	base := c.endpoint()
+	if base == "" {
+		return errors.New("no endpoints configured")
+	}
+	resp, err := c.HTTPClient.Get(fmt.Sprintf("%s/audit/export?from=%d", base, from))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(data))}
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// AuditProof 为审计条目的 Merkle 包含性证明及节点签名的树根。
+type AuditProof struct {
+	Index     uint64   `json:"index"`
+	Entry     Entry    `json:"entry"`
+	TreeSize  uint64   `json:"tree_size"`
+	Root      string   `json:"root"`
+	Proof     []string `json:"proof"`
+	Signer    string   `json:"signer"`
+	Signature string   `json:"signature"`
+}
+
+// GetAuditProof 获取审计条目 index 的包含性证明，treeSize 为 0 时使用当前链头。
+func (c *Client) GetAuditProof(index, treeSize uint64) (*AuditProof, error) {
+	path := fmt.Sprintf("/audit/%d/proof", index)
+	if treeSize != 0 {
+		path += fmt.Sprintf("?tree_size=%d", treeSize)
+	}
+	var p AuditProof
+	if err := c.do(http.MethodGet, path, nil, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Verify 在本地校验证明：条目哈希、Merkle 路径以及签名者对树根的签名。
+// trustedSigners 为调用方信任的节点身份地址，签名者不在其中时返回 ErrUntrustedSigner。
+func (p *AuditProof) Verify(trustedSigners []string) error {
+	if err := checkSigner(p.Signer, trustedSigners); err != nil {
+		return err
+	}
+	root, err := decodeHash(p.Root)
+	if err != nil {
+		return fmt.Errorf("invalid root: %v", err)
+	}
+	proof := make([][32]byte, len(p.Proof))
+	for i, h := range p.Proof {
+		if proof[i], err = decodeHash(h); err != nil {
+			return fmt.Errorf("invalid proof node %d: %v", i, err)
+		}
+	}
+	if p.Entry.Index != p.Index {
+		return errors.New("entry index mismatch")
+	}
+	if !audit.VerifyEntryInclusion(&p.Entry, p.TreeSize, proof, root) {
+		return errors.New("inclusion proof verification failed")
+	}
+	sig, err := hex.DecodeString(p.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %v", err)
+	}
+	if !audit.VerifySignedRoot(p.Signer, p.TreeSize, root, sig) {
+		return errors.New("root signature verification failed")
+	}
+	return nil
+}
+
+// ConsistencyProof 为两个审计树大小之间的一致性证明及节点签名的新树根。
+type ConsistencyProof struct {
+	OldSize   uint64   `json:"old_size"`
+	OldRoot   string   `json:"old_root"`
+	NewSize   uint64   `json:"new_size"`
+	NewRoot   string   `json:"new_root"`
+	Proof     []string `json:"proof"`
+	Signer    string   `json:"signer"`
+	Signature string   `json:"signature"`
+}
+
+// GetConsistencyProof 获取从 oldSize 到 newSize 的一致性证明，newSize 为 0 时使用当前链头。
+func (c *Client) GetConsistencyProof(oldSize, newSize uint64) (*ConsistencyProof, error) {
+	path := fmt.Sprintf("/audit/consistency?old_size=%d", oldSize)
+	if newSize != 0 {
+		path += fmt.Sprintf("&new_size=%d", newSize)
+	}
+	var p ConsistencyProof
+	if err := c.do(http.MethodGet, path, nil, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Verify 在本地校验证明：新树是根为 trustedOldRoot 的旧树的追加扩展，且新树根由 trustedSigners 中的节点签名。
+// trustedOldRoot 应来自审计方此前保存的快照，而不是本次响应。
+func (p *ConsistencyProof) Verify(trustedOldRoot string, trustedSigners []string) error {
+	if err := checkSigner(p.Signer, trustedSigners); err != nil {
+		return err
+	}
+	oldRoot, err := decodeHash(trustedOldRoot)
+	if err != nil {
+		return fmt.Errorf("invalid old root: %v", err)
+	}
+	newRoot, err := decodeHash(p.NewRoot)
+	if err != nil {
+		return fmt.Errorf("invalid new root: %v", err)
+	}
+	proof := make([][32]byte, len(p.Proof))
+	for i, h := range p.Proof {
+		if proof[i], err = decodeHash(h); err != nil {
+			return fmt.Errorf("invalid proof node %d: %v", i, err)
+		}
+	}
+	if !audit.VerifyConsistency(p.OldSize, p.NewSize, oldRoot, newRoot, proof) {
+		return errors.New("consistency proof verification failed")
+	}
+	sig, err := hex.DecodeString(p.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %v", err)
+	}
+	if !audit.VerifySignedRoot(p.Signer, p.NewSize, newRoot, sig) {
+		return errors.New("root signature verification failed")
+	}
+	return nil
+}
+
+// ErrUntrustedSigner 表示签名者不在调用方信任的节点身份集合中。
+var ErrUntrustedSigner = errors.New("signer is not a trusted node identity")
+
+// checkSigner 确认签名者属于 trusted。响应中的 signer 可由任意节点或中间人替换为自己的密钥，
+// trusted 必须来自带外渠道（如运维核对后分发的节点身份地址），不能取自本次响应。
+func checkSigner(signer string, trusted []string) error {
+	for _, t := range trusted {
+		if t != "" && t == signer {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrUntrustedSigner, signer)
+}
+
+func decodeHash(s string) ([32]byte, error) {
+	var h [32]byte
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return h, err
+	}
+	if len(b) != len(h) {
+		return h, errors.New("hash must be 32 bytes")
+	}
+	copy(h[:], b)
+	return h, nil
+}
+
+// Supply 为全网发行量；AccountsTotal 与 Consistent 仅在校验时返回。
+type Supply struct {
+	TotalSupply   uint64 `json:"total_supply"`
+	Genesis       uint64 `json:"genesis"`
+	Minted        uint64 `json:"minted"`
+	Burned        uint64 `json:"burned"`
+	AccountsTotal uint64 `json:"accounts_total,omitempty"`
+	Consistent    bool   `json:"consistent,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// Supply 查询全网发行量；verify 为 true 时节点同时校验所有账户余额之和等于发行量。
+func (c *Client) Supply(verify bool) (*Supply, error) {
+	path := "/ledger/supply"
+	if verify {
+		path += "?verify=true"
+	}
+	var sup Supply
+	if err := c.do(http.MethodGet, path, nil, &sup); err != nil {
+		return nil, err
+	}
+	return &sup, nil
+}
+
+// PolicyInfo 为节点返回的额度策略；查询指定地址时附带该账户生效的限制与使用量。
+type PolicyInfo struct {
+	// Policy 为 nil 表示尚未设置策略，不限制额度
+	Policy  *Policy `json:"policy"`
+	Address string  `json:"address,omitempty"`
+	Limits  *Limits `json:"limits,omitempty"`
+	Usage   *Usage  `json:"usage,omitempty"`
+}
+
+// GetPolicy 查询当前额度策略；address 非空时同时返回该账户生效的限制与使用量。
+func (c *Client) GetPolicy(address string) (*PolicyInfo, error) {
+	q := url.Values{}
+	if address != "" {
+		q.Set("address", address)
+	}
+	if c.ReadConsistency != "" {
+		q.Set("consistency", c.ReadConsistency)
+	}
+	path := "/ledger/policy"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	var info PolicyInfo
+	if err := c.do(http.MethodGet, path, nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// AuditVerifyProgress 为节点后台全量审计校验的进度。
+type AuditVerifyProgress struct {
+	Running    bool   `json:"running"`
+	Verified   uint64 `json:"verified"`
+	Target     uint64 `json:"target"`
+	StartedAt  int64  `json:"started_at"`
+	FinishedAt int64  `json:"finished_at"`
+	Error      string `json:"error"`
+}
+
+// AuditVerifyStatus 以 requester（管理员或创世者）的身份查询节点后台全量审计校验的进度。
+func (c *Client) AuditVerifyStatus(requester string) (*AuditVerifyProgress, error) {
+	var p AuditVerifyProgress
+	headers, err := c.requestHeaders(requester, http.MethodGet, "/admin/audit/verify")
+	if err != nil {
+		return nil, err
+	}
+	if err := c.doWithHeaders(http.MethodGet, "/admin/audit/verify", headers, nil, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// StartAuditVerify 以 requester（管理员或创世者）的身份触发节点重新进行一次后台全量审计校验。
+func (c *Client) StartAuditVerify(requester string) (*AuditVerifyProgress, error) {
+	headers, err := c.requestHeaders(requester, http.MethodPost, "/admin/audit/verify")
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Progress AuditVerifyProgress `json:"progress"`
+	}
+	if err := c.doWithHeaders(http.MethodPost, "/admin/audit/verify", headers, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Progress, nil
+}
+
+// ClearAuditDivergence 以 requester（管理员或创世者）的身份清除节点持久化的审计链分叉记录，恢复其写入。
+// 分叉记录属于单个副本，请求只作用于实际接收它的节点，调用方应只配置目标节点一个地址。
+// 返回被清除的分叉记录，节点未分叉时为 nil。
+func (c *Client) ClearAuditDivergence(requester string) (*AuditDivergence, error) {
+	headers, err := c.requestHeaders(requester, http.MethodPost, "/admin/audit/divergence/clear")
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Divergence *AuditDivergence `json:"divergence"`
+	}
+	if err := c.doWithHeaders(http.MethodPost, "/admin/audit/divergence/clear", headers, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Divergence, nil
+}
+
+// Join 请求集群接纳新节点，httpAddr 为新节点对外的 HTTP 地址，可为空。
+func (c *Client) Join(nodeID, raftAddr, httpAddr string) error {
+	body := map[string]string{"node_id": nodeID, "raft_address": raftAddr, "http_address": httpAddr}
+	return c.do(http.MethodPost, "/raft/join", body, nil)
+}
+
+// Remove 请求集群移除节点。
+func (c *Client) Remove(nodeID string) error {
+	body := map[string]string{"node_id": nodeID}
+	return c.do(http.MethodPost, "/raft/remove", body, nil)
+}
+
+// RaftStatus 返回当前连接节点的 Raft 状态。
+func (c *Client) RaftStatus() (map[string]interface{}, error) {
+	res := map[string]interface{}{}
+	if err := c.do(http.MethodGet, "/raft/status", nil, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ResetNonce 丢弃本地缓存的 nonce，下次发送时重新从节点读取。
+func (c *Client) ResetNonce(sender string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.nonces, sender)
+}
+
+// chainID 返回签名使用的 chain id，未配置时从节点读取并缓存。
+func (c *Client) chainID() (string, error) {
+	c.mu.Lock()
+	chainID := c.ChainID
+	c.mu.Unlock()
+	if chainID != "" {
+		return chainID, nil
+	}
+	st, err := c.RaftStatus()
+	if err != nil {
+		return "", err
+	}
+	chainID, _ = st["chain_id"].(string)
+	if chainID == "" {
+		return "", errors.New("node did not report chain_id")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ChainID = chainID
+	return chainID, nil
+}
+
+// nextNonce 在锁内为发送方预留下一笔交易的 nonce，并发发送时各自得到不同的值；
+// 本地没有缓存时先从节点读取账户 nonce。
+func (c *Client) nextNonce(sender string) (uint64, error) {
+	for {
+		c.mu.Lock()
+		if last, ok := c.nonces[sender]; ok {
+			c.nonces[sender] = last + 1
+			c.mu.Unlock()
+			return last + 1, nil
+		}
+		c.mu.Unlock()
+		acc, err := c.GetAccount(sender)
+		if err != nil {
+			return 0, err
+		}
+		c.mu.Lock()
+		if _, ok := c.nonces[sender]; !ok {
+			c.nonces[sender] = acc.Nonce
+		}
+		c.mu.Unlock()
+	}
+}
+
+// lockSender 锁定发送方直到返回的函数被调用；nonce 严格递增，乱序到达的交易会被拒绝
+func (c *Client) lockSender(sender string) func() {
+	c.mu.Lock()
+	m, ok := c.senders[sender]
+	if !ok {
+		m = &sync.Mutex{}
+		c.senders[sender] = m
+	}
+	c.mu.Unlock()
+	m.Lock()
+	return m.Unlock
+}
+
+// releaseNonce 在发送失败后归还预留的 nonce：节点明确拒绝（4xx，nonce 冲突除外）且它仍是最后一个预留值时回退；
+// 否则结果未知或之后已有其它预留，丢弃缓存，下次从节点重新读取。
+func (c *Client) releaseNonce(sender string, nonce uint64, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode < 500 && apiErr.Code != CodeNonceMismatch && c.nonces[sender] == nonce {
+		c.nonces[sender] = nonce - 1
+		return
+	}
+	delete(c.nonces, sender)
+}
+
+func (c *Client) do(method, path string, body, out interface{}) error {
+	return c.doWithHeaders(method, path, nil, body, out)
+}
+
+// idempotent 判断请求能否在结果未知时重发：GET 无副作用，已签名交易按哈希与 nonce 去重。
+func idempotent(method, path string) bool {
+	return method == http.MethodGet || path == "/transactions/submit"
+}
+
+// 单次请求最多跟随 X-Raft-Leader 转向的次数
+const maxLeaderRedirects = 3
+
+// doWithHeaders 发送请求：遇到 X-Raft-Leader 时转向 leader；幂等请求在网络错误或 5xx 时轮换节点重试，
+// 其余请求直接返回错误，避免重复执行。
+func (c *Client) doWithHeaders(method, path string, headers map[string]string, body, out interface{}) error {
+	retry := idempotent(method, path)
+	var payload []byte
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = b
+	}
+
+	var lastErr error
+	leader := ""
+	redirects := 0
+	for attempt := 0; attempt <= c.MaxRetries; attempt++ {
+		if attempt > 0 && leader == "" {
+			time.Sleep(c.RetryBackoff * time.Duration(attempt))
+		}
+		base := leader
+		if base == "" {
+			base = c.endpoint()
+		}
+		leader = ""
+		if base == "" {
+			return errors.New("no endpoints configured")
+		}
+
+		var reader io.Reader
+		if payload != nil {
+			reader = bytes.NewReader(payload)
+		}
+		req, err := http.NewRequest(method, base+path, reader)
+		if err != nil {
+			return err
+		}
+		if payload != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := c.HTTPClient.Do(req)
+		if err != nil {
+			c.rotate()
+			if !retry {
+				return err
+			}
+			lastErr = err
+			continue
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			c.rotate()
+			if !retry {
+				return err
+			}
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			if out == nil || len(data) == 0 {
+				return nil
+			}
+			return json.Unmarshal(data, out)
+		}
+
+		apiErr := &APIError{StatusCode: resp.StatusCode, Leader: resp.Header.Get("X-Raft-Leader")}
+		var msg struct {
+			Error      string   `json:"error"`
+			Code       string   `json:"code"`
+			RaftLeader string   `json:"raft_leader"`
+			Receipt    *Receipt `json:"receipt"`
+		}
+		if json.Unmarshal(data, &msg) == nil && msg.Error != "" {
+			apiErr.Message = msg.Error
+			apiErr.Code = msg.Code
+			apiErr.Receipt = msg.Receipt
+		} else {
+			apiErr.Message = strings.TrimSpace(string(data))
+		}
+		lastErr = apiErr
+		// raft_leader 表示 X-Raft-Leader 只是 Raft 地址，无法直接发送 HTTP 请求。
+		// 转向 leader 不占用重试次数，但单独限次，避免节点互指时空转；超过后按普通错误轮换退避
+		if apiErr.Leader != "" && msg.RaftLeader == "" && redirects < maxLeaderRedirects {
+			redirects++
+			leader = normalizeEndpoint(apiErr.Leader)
+			attempt--
+			continue
+		}
+		// not_leader 表示请求未被执行，任何接口都可以换节点重发
+		if resp.StatusCode >= 500 && (retry || apiErr.Code == CodeNotLeader) {
+			c.rotate()
+			continue
+		}
+		return apiErr
+	}
+	return lastErr
+}
+
+func (c *Client) endpoint() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.endpoints) == 0 {
+		return ""
+	}
+	return c.endpoints[c.current%len(c.endpoints)]
+}
+
+func (c *Client) rotate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.current++
+}
+
+func normalizeEndpoint(ep string) string {
+	ep = strings.TrimRight(ep, "/")
+	if !strings.HasPrefix(ep, "http://") && !strings.HasPrefix(ep, "https://") {
+		ep = "http://" + ep
+	}
+	return ep
+}
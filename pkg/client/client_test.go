@@ -0,0 +1,64 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"distributed_ledger_go/pkg/types"
+)
+
+func TestLeaderRedirectLoopIsBounded(t *testing.T) {
+	var hits int32
+	var a, b *httptest.Server
+	a = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("X-Raft-Leader", b.URL)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error":"not leader","code":"not_leader"}`))
+	}))
+	defer a.Close()
+	b = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("X-Raft-Leader", a.URL)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error":"not leader","code":"not_leader"}`))
+	}))
+	defer b.Close()
+
+	c := New(a.URL)
+	c.MaxRetries = 1
+	c.RetryBackoff = 0
+	err := c.do(http.MethodGet, "/supply", nil, nil)
+	if ErrorCode(err) != CodeNotLeader {
+		t.Fatalf("err = %v, want not_leader", err)
+	}
+	// 转向次数用尽后按普通重试轮换节点
+	if want := int32(c.MaxRetries + 1 + maxLeaderRedirects); atomic.LoadInt32(&hits) != want {
+		t.Fatalf("requests = %d, want %d", atomic.LoadInt32(&hits), want)
+	}
+}
+
+func TestSubmitSignedRecoversFailedReceipt(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"error":"nonce mismatch","code":"nonce_mismatch"}`))
+			return
+		}
+		w.Write([]byte(`{"tx_hash":"` + strings.TrimPrefix(r.URL.Path, "/transactions/") + `","status":"failed","error":"insufficient balance"}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL)
+	r, err := c.SubmitSigned(Transaction{ChainID: "test", Type: TxTypeTransfer, Sender: "alice", Receiver: "bob", Amount: 1, Nonce: 1})
+	if !errors.Is(err, ErrTxFailed) {
+		t.Fatalf("err = %v, want ErrTxFailed", err)
+	}
+	if r == nil || r.Status != types.ReceiptStatusFailed || r.Error != "insufficient balance" {
+		t.Fatalf("receipt = %+v, want the failed receipt of the earlier attempt", r)
+	}
+}
@@ -0,0 +1,151 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"distributed_ledger_go/pkg/crypto"
+)
+
+const (
+	keyFileSuffix = ".key"
+	// addressLen 为地址（未压缩公钥 X||Y 的 hex）长度
+	addressLen = 128
+)
+
+// Keystore 在本地目录中按地址保存私钥（hex），私钥不会离开本机。
+type Keystore struct {
+	dir  string
+	mu   sync.RWMutex
+	keys map[string]*ecdsa.PrivateKey
+}
+
+// NewKeystore 打开（必要时创建）指定目录下的密钥库。
+func NewKeystore(dir string) (*Keystore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &Keystore{dir: dir, keys: map[string]*ecdsa.PrivateKey{}}, nil
+}
+
+// Generate 生成新的密钥对并持久化，返回地址。
+func (ks *Keystore) Generate() (string, error) {
+	priv, addr, err := crypto.GenerateKeyPair()
+	if err != nil {
+		return "", err
+	}
+	if err := ks.store(addr, priv); err != nil {
+		return "", err
+	}
+	return addr, nil
+}
+
+// Import 导入 hex 私钥并持久化，返回对应地址。
+func (ks *Keystore) Import(privHex string) (string, error) {
+	priv, err := crypto.HexToPrivateKey(privHex)
+	if err != nil {
+		return "", err
+	}
+	addr, err := crypto.PublicKeyToHex(&priv.PublicKey)
+	if err != nil {
+		return "", err
+	}
+	if err := ks.store(addr, priv); err != nil {
+		return "", err
+	}
+	return addr, nil
+}
+
+// Get 返回地址对应的私钥。
+func (ks *Keystore) Get(address string) (*ecdsa.PrivateKey, error) {
+	ks.mu.RLock()
+	priv, ok := ks.keys[address]
+	ks.mu.RUnlock()
+	if ok {
+		return priv, nil
+	}
+	p, err := ks.path(address)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("key not found in keystore: %s", address)
+	}
+	if err != nil {
+		return nil, err
+	}
+	priv, err = crypto.HexToPrivateKey(strings.TrimSpace(string(raw)))
+	if err != nil {
+		return nil, err
+	}
+	ks.mu.Lock()
+	ks.keys[address] = priv
+	ks.mu.Unlock()
+	return priv, nil
+}
+
+// List 返回密钥库中的全部地址。
+func (ks *Keystore) List() ([]string, error) {
+	files, err := os.ReadDir(ks.dir)
+	if err != nil {
+		return nil, err
+	}
+	var addrs []string
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), keyFileSuffix) {
+			continue
+		}
+		addr := strings.TrimSuffix(f.Name(), keyFileSuffix)
+		if validAddress(addr) != nil {
+			continue
+		}
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	return addrs, nil
+}
+
+func (ks *Keystore) store(address string, priv *ecdsa.PrivateKey) error {
+	privHex, err := crypto.PrivateKeyToHex(priv)
+	if err != nil {
+		return err
+	}
+	p, err := ks.path(address)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(p, []byte(privHex+"\n"), 0o600); err != nil {
+		return err
+	}
+	ks.mu.Lock()
+	ks.keys[address] = priv
+	ks.mu.Unlock()
+	return nil
+}
+
+// path 返回地址对应的密钥文件路径；地址必须是 128 位小写 hex，防止拼出目录外的路径。
+func (ks *Keystore) path(address string) (string, error) {
+	if err := validAddress(address); err != nil {
+		return "", err
+	}
+	return filepath.Join(ks.dir, address+keyFileSuffix), nil
+}
+
+func validAddress(address string) error {
+	if len(address) != addressLen {
+		return fmt.Errorf("invalid address: expected %d hex characters, got %d", addressLen, len(address))
+	}
+	for _, r := range address {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return fmt.Errorf("invalid address: non-hex character %q", r)
+		}
+	}
+	return nil
+}
@@ -8,6 +8,9 @@ import (
 	"encoding/hex"
 	"errors"
 	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
 )
 
 // PublicKeyToHex 将公钥编码成稳定的 hex 字符串（长度恒为 128）。
@@ -123,3 +126,37 @@ func HexToPrivateKey(hexKey string) (*ecdsa.PrivateKey, error) {
 	priv.PublicKey.X, priv.PublicKey.Y = curve.ScalarBaseMult(raw)
 	return priv, nil
 }
+
+// LoadOrCreateKey 从 path 读取 hex 私钥，不存在时生成新密钥并以 0600 权限保存，返回私钥与地址。
+func LoadOrCreateKey(path string) (*ecdsa.PrivateKey, string, error) {
+	raw, err := os.ReadFile(path)
+	if err == nil {
+		priv, err := HexToPrivateKey(strings.TrimSpace(string(raw)))
+		if err != nil {
+			return nil, "", err
+		}
+		addr, err := PublicKeyToHex(&priv.PublicKey)
+		if err != nil {
+			return nil, "", err
+		}
+		return priv, addr, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, "", err
+	}
+	priv, addr, err := GenerateKeyPair()
+	if err != nil {
+		return nil, "", err
+	}
+	privHex, err := PrivateKeyToHex(priv)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, "", err
+	}
+	if err := os.WriteFile(path, []byte(privHex+"\n"), 0o600); err != nil {
+		return nil, "", err
+	}
+	return priv, addr, nil
+}
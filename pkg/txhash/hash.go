@@ -0,0 +1,145 @@
+// Package txhash 定义交易、查询、角色变更签名载荷与创世配置的哈希，节点与客户端 SDK 共用，不依赖存储层。
+package txhash
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"distributed_ledger_go/pkg/types"
+	"encoding/binary"
+	"encoding/hex"
+	"sort"
+)
+
+// 签名载荷版本号，载荷格式变化时递增
+const TxHashVersion byte = 1
+
+// 域分隔标签，区分交易签名、请求签名与创世哈希
+const (
+	txDomain      = "ledger/tx"
+	roleDomain    = "ledger/role"
+	requestDomain = "ledger/request"
+	genesisDomain = "ledger/genesis"
+)
+
+// 生成交易哈希（不包含签名字段，避免循环依赖）
+//
+// 规范签名载荷（大端序，依次拼接后取 SHA-256；lp(x) 表示 uint32 长度前缀 + 原始字节）：
+//
+//	lp("ledger/tx") || byte(Version=1) || lp(ChainID) || int32(Type) || lp(Sender) || lp(Receiver) ||
+//	uint64(Amount) || uint64(Nonce) || int64(ValidUntil)
+//
+// TxTypeSetPolicy 交易在末尾追加 lp(Data)，其余类型的载荷保持不变。
+//
+// 离线钱包应对 TxHash 的结果再做一次 SHA-256，并使用 P-256 私钥生成 ASN.1 DER 格式的
+// ECDSA 签名（与 crypto.Sign 行为一致），最终以 hex 形式提交到 /transactions/submit。
+func TxHash(tx types.Transaction) []byte {
+	res := new(bytes.Buffer)
+	writeLP(res, []byte(txDomain))
+	res.WriteByte(TxHashVersion)
+	writeLP(res, []byte(tx.ChainID))
+	_ = binary.Write(res, binary.BigEndian, int32(tx.Type))
+	writeLP(res, []byte(tx.Sender))
+	writeLP(res, []byte(tx.Receiver))
+	_ = binary.Write(res, binary.BigEndian, tx.Amount)
+	_ = binary.Write(res, binary.BigEndian, tx.Nonce)
+	_ = binary.Write(res, binary.BigEndian, tx.ValidUntil)
+	if tx.Type == types.TxTypeSetPolicy {
+		writeLP(res, tx.Data)
+	}
+
+	hash := sha256.Sum256(res.Bytes())
+	return hash[:]
+}
+
+// 生成升级到 TxHashVersion 1 之前的交易哈希（无 chain id、无域分隔与长度前缀），仅用于重放旧 Raft 日志：
+//
+//	int32(Type) || Sender(UTF-8 字节) || Receiver(UTF-8 字节) || uint64(Amount) || uint64(Nonce) [|| int64(ValidUntil)]
+//
+// withValidUntil 区分引入 valid_until 前后的两种旧载荷。
+func LegacyTxHash(tx types.Transaction, withValidUntil bool) []byte {
+	res := new(bytes.Buffer)
+	_ = binary.Write(res, binary.BigEndian, int32(tx.Type))
+	res.WriteString(tx.Sender)
+	res.WriteString(tx.Receiver)
+	_ = binary.Write(res, binary.BigEndian, tx.Amount)
+	_ = binary.Write(res, binary.BigEndian, tx.Nonce)
+	if withValidUntil {
+		_ = binary.Write(res, binary.BigEndian, tx.ValidUntil)
+	}
+
+	hash := sha256.Sum256(res.Bytes())
+	return hash[:]
+}
+
+// 生成签名请求（流水查询与运维接口）哈希，签名绑定 HTTP 方法、路径与一次性 nonce，不能被挪用到其它接口或重放，签名方式与 TxHash 相同。
+//
+//	lp("ledger/request") || byte(Version=1) || lp(ChainID) || lp(Requester) || lp(Method) || lp(Path) ||
+//	int64(Timestamp) || lp(Nonce)
+func RequestHash(chainID, requester, method, path string, timestamp int64, nonce string) []byte {
+	res := new(bytes.Buffer)
+	writeLP(res, []byte(requestDomain))
+	res.WriteByte(TxHashVersion)
+	writeLP(res, []byte(chainID))
+	writeLP(res, []byte(requester))
+	writeLP(res, []byte(method))
+	writeLP(res, []byte(path))
+	_ = binary.Write(res, binary.BigEndian, timestamp)
+	writeLP(res, []byte(nonce))
+
+	hash := sha256.Sum256(res.Bytes())
+	return hash[:]
+}
+
+// 生成角色变更请求哈希，签名方式与 TxHash 相同；nonce 为创世者当前 nonce + 1，防止签名被重放。
+//
+//	lp("ledger/role") || byte(Version=1) || lp(ChainID) || lp(Creator) || lp(Target) || lp(Role) || uint64(Nonce)
+func RoleHash(chainID, creator, target, role string, nonce uint64) []byte {
+	res := new(bytes.Buffer)
+	writeLP(res, []byte(roleDomain))
+	res.WriteByte(TxHashVersion)
+	writeLP(res, []byte(chainID))
+	writeLP(res, []byte(creator))
+	writeLP(res, []byte(target))
+	writeLP(res, []byte(role))
+	_ = binary.Write(res, binary.BigEndian, nonce)
+
+	hash := sha256.Sum256(res.Bytes())
+	return hash[:]
+}
+
+// 写入带 uint32 长度前缀的字段，消除变长字段之间的边界歧义
+func writeLP(buf *bytes.Buffer, b []byte) {
+	_ = binary.Write(buf, binary.BigEndian, uint32(len(b)))
+	buf.Write(b)
+}
+
+// 生成创世配置哈希（hex），集群各节点据此确认使用同一份创世配置。管理员与余额按地址排序后编码：
+//
+//	lp("ledger/genesis") || byte(Version=1) || lp(ChainID) || lp(Creator) ||
+//	uint32(len(Admins)) || lp(Admin)... || uint32(len(Balances)) || (lp(Address) || uint64(Amount))...
+func GenesisHash(g *types.Genesis) string {
+	res := new(bytes.Buffer)
+	writeLP(res, []byte(genesisDomain))
+	res.WriteByte(TxHashVersion)
+	writeLP(res, []byte(g.ChainID))
+	writeLP(res, []byte(g.Creator))
+	admins := append([]string(nil), g.Admins...)
+	sort.Strings(admins)
+	_ = binary.Write(res, binary.BigEndian, uint32(len(admins)))
+	for _, a := range admins {
+		writeLP(res, []byte(a))
+	}
+	addrs := make([]string, 0, len(g.Balances))
+	for addr := range g.Balances {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	_ = binary.Write(res, binary.BigEndian, uint32(len(addrs)))
+	for _, addr := range addrs {
+		writeLP(res, []byte(addr))
+		_ = binary.Write(res, binary.BigEndian, g.Balances[addr])
+	}
+
+	hash := sha256.Sum256(res.Bytes())
+	return hex.EncodeToString(hash[:])
+}
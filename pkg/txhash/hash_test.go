@@ -0,0 +1,74 @@
+package txhash
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"distributed_ledger_go/pkg/types"
+)
+
+// 签名载荷是离线钱包与节点之间的约定，固定哈希值防止编码被无意改动
+func TestTxHashGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		tx   types.Transaction
+		want string
+	}{
+		{
+			name: "transfer",
+			tx: types.Transaction{
+				ChainID:    "ledger-dev",
+				Type:       types.TxTypeTransfer,
+				Sender:     "alice",
+				Receiver:   "bob",
+				Amount:     42,
+				Nonce:      7,
+				ValidUntil: 1700000000,
+			},
+			want: "c83e0472ca324e8d67f82e90eaafe4741b0d90a52755e1e98c4859b3d3ba780a",
+		},
+		{
+			name: "set policy carries data",
+			tx: types.Transaction{
+				ChainID:  "ledger-dev",
+				Type:     types.TxTypeSetPolicy,
+				Sender:   "alice",
+				Receiver: "alice",
+				Nonce:    8,
+				Data:     []byte(`{"max_supply":1000000}`),
+			},
+			want: "74d26157e1efc507d288e6bf2b6d928cafa2486363c88ec22ace7468ce1dc94a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hex.EncodeToString(TxHash(tt.tx)); got != tt.want {
+				t.Fatalf("TxHash = %s, want %s", got, tt.want)
+			}
+			// 签名不参与哈希
+			signed := tt.tx
+			signed.Signature = []byte{0x01}
+			if got := hex.EncodeToString(TxHash(signed)); got != tt.want {
+				t.Fatalf("TxHash depends on signature: %s", got)
+			}
+		})
+	}
+}
+
+// 旧格式只用于重放升级前的 Raft 日志，哈希必须与当时的实现逐字节一致
+func TestLegacyTxHashGolden(t *testing.T) {
+	tx := types.Transaction{
+		Type:       types.TxTypeTransfer,
+		Sender:     "alice",
+		Receiver:   "bob",
+		Amount:     42,
+		Nonce:      7,
+		ValidUntil: 1700000000,
+	}
+	if got := hex.EncodeToString(LegacyTxHash(tx, false)); got != "26d404e59a895b61c9797effebd2fe1744f991e4e55c6a1e7130349774f0ce47" {
+		t.Fatalf("LegacyTxHash without valid_until = %s", got)
+	}
+	if got := hex.EncodeToString(LegacyTxHash(tx, true)); got != "3482f6fde8ddc7f37ee25a0caf401190bb66f5bfe2ac94816f8c100d0cbcf268" {
+		t.Fatalf("LegacyTxHash with valid_until = %s", got)
+	}
+}
@@ -0,0 +1,14 @@
+package types
+
+// 审计记录
+type Entry struct {
+	Index     uint64
+	Version   byte // TxBytes 的编码版本，见 pkg/audit.PayloadVersion
+	PrevHash  [32]byte
+	TxBytes   []byte
+	EntryHash [32]byte
+	// 以下元数据仅版本 2 起的条目携带：leader 提议时间（Unix 秒，单调不减）、提交任期与 Raft 日志索引
+	Timestamp int64
+	Term      uint64
+	RaftIndex uint64
+}
@@ -0,0 +1,33 @@
+package types
+
+// 审计链检查点：leader 用节点身份密钥对某一时刻链头的签名声明
+type Checkpoint struct {
+	Index     uint64 `json:"index"`
+	LastHash  string `json:"last_hash"`
+	Term      uint64 `json:"term"`
+	Timestamp int64  `json:"timestamp"`
+	Signer    string `json:"signer"`
+	Signature string `json:"signature"`
+}
+
+// 副本对检查点的回报：follower 用节点身份密钥声明自己在检查点索引处的本地条目哈希，
+// leader 汇总多数派的回报判断自身审计链是否分叉
+type CheckpointReport struct {
+	NodeID    string `json:"node_id"`
+	Index     uint64 `json:"index"`
+	LocalHash string `json:"local_hash"`
+	Timestamp int64  `json:"timestamp"`
+	Signer    string `json:"signer"`
+	Signature string `json:"signature"`
+}
+
+// 本地审计链与集群不一致的记录；Source 为 checkpoint（与 leader 检查点不一致）或 quorum（与多数副本回报不一致），
+// DetectedAt 为触发比对的检查点或回报的时间戳
+type AuditDivergence struct {
+	Index      uint64 `json:"index"`
+	Expected   string `json:"expected"`
+	Local      string `json:"local"`
+	Term       uint64 `json:"term"`
+	Source     string `json:"source"`
+	DetectedAt int64  `json:"detected_at"`
+}
@@ -0,0 +1,11 @@
+package types
+
+// 创世配置：集群初始的创世者、管理员与余额，由 genesis.json 加载并经 Raft 写入各副本
+type Genesis struct {
+	ChainID  string            `json:"chain_id"`
+	Creator  string            `json:"creator"`
+	Admins   []string          `json:"admins,omitempty"`
+	Balances map[string]uint64 `json:"balances,omitempty"`
+	// 文件中声明的创世哈希，为空时以计算结果为准；不参与哈希计算
+	GenesisHash string `json:"genesis_hash,omitempty"`
+}
@@ -0,0 +1,13 @@
+package types
+
+// 账户流水记录，由审计索引派生，用于按地址分页查询
+type HistoryRecord struct {
+	Index    uint64 `json:"index"`
+	Type     TxType `json:"type"`
+	Sender   string `json:"sender"`
+	Receiver string `json:"receiver"`
+	Amount   uint64 `json:"amount"`
+	Nonce    uint64 `json:"nonce"`
+	// 交易写入审计链的时间（Unix 秒），升级前的记录为 0
+	Timestamp int64 `json:"timestamp,omitempty"`
+}
@@ -0,0 +1,49 @@
+package types
+
+// 额度限制，各字段为 0 表示不限制
+type Limits struct {
+	// 单笔铸币、转账或销毁的最大金额
+	MaxAmount uint64 `json:"max_amount,omitempty"`
+	// 每个 UTC 自然日转出（转账与销毁）的累计上限
+	DailyLimit uint64 `json:"daily_limit,omitempty"`
+	// 累计铸币上限，自首次设置策略起计算
+	MintCap uint64 `json:"mint_cap,omitempty"`
+}
+
+// 额度策略，由创始者通过 TxTypeSetPolicy 交易设置并随 Raft 复制。
+// 账户级限制存在时整体替代该账户所属角色的限制。
+type Policy struct {
+	// 全网发行量上限，0 表示不限制
+	MaxSupply uint64            `json:"max_supply,omitempty"`
+	Roles     map[string]Limits `json:"roles,omitempty"`
+	Accounts  map[string]Limits `json:"accounts,omitempty"`
+}
+
+// 返回地址在策略下生效的限制
+func (p *Policy) LimitsFor(address, role string) Limits {
+	if l, ok := p.Accounts[address]; ok {
+		return l
+	}
+	if role == "" {
+		role = RoleUser
+	}
+	return p.Roles[role]
+}
+
+// 账户在额度策略下的使用量，仅在策略生效期间随交易更新
+type Usage struct {
+	// Spent 所属的 UTC 自然日（Unix 秒 / 86400）
+	Day int64 `json:"day"`
+	// 当日转出（转账与销毁）累计
+	Spent uint64 `json:"spent"`
+	// 累计铸币
+	Minted uint64 `json:"minted"`
+}
+
+// 返回 day 当天已转出的金额，跨日后计数归零
+func (u *Usage) SpentOn(day int64) uint64 {
+	if u.Day != day {
+		return 0
+	}
+	return u.Spent
+}
@@ -0,0 +1,18 @@
+package types
+
+const (
+	ReceiptStatusSuccess = "success"
+	ReceiptStatusFailed  = "failed"
+)
+
+// 交易回执
+type Receipt struct {
+	TxHash          string `json:"tx_hash"`
+	Status          string `json:"status"`
+	Error           string `json:"error,omitempty"`
+	RaftIndex       uint64 `json:"raft_index"`
+	AuditIndex      uint64 `json:"audit_index,omitempty"`
+	EntryHash       string `json:"entry_hash,omitempty"`
+	SenderBalance   uint64 `json:"sender_balance"`
+	ReceiverBalance uint64 `json:"receiver_balance"`
+}
@@ -0,0 +1,10 @@
+package types
+
+// 全网发行量：TotalSupply 恒等于所有账户余额之和，也等于 Genesis + Minted - Burned；
+// Genesis 为创世余额，Minted/Burned 为累计铸币与销毁数量
+type Supply struct {
+	TotalSupply uint64 `json:"total_supply"`
+	Genesis     uint64 `json:"genesis"`
+	Minted      uint64 `json:"minted"`
+	Burned      uint64 `json:"burned"`
+}
@@ -0,0 +1,50 @@
+package types
+
+// 表示交易类型。
+type TxType int
+
+const (
+	TxTypeMint TxType = iota
+	TxTypeTransfer
+	TxTypeFreeze
+	TxTypeUnfreeze
+	// 销毁发送方自己的余额，用于管理员在链下兑付后回收代币；Receiver 必须等于 Sender
+	TxTypeBurn
+	// 由创始者设置额度策略，Data 为 JSON 编码的 Policy；Receiver 必须等于 Sender，Amount 必须为 0
+	TxTypeSetPolicy
+)
+
+var txPermissions = map[TxType][]string{
+	TxTypeMint:      {RoleCreator},
+	TxTypeTransfer:  {RoleCreator, RoleAdmin, RoleUser},
+	TxTypeFreeze:    {RoleCreator, RoleAdmin},
+	TxTypeUnfreeze:  {RoleCreator, RoleAdmin},
+	TxTypeBurn:      {RoleCreator, RoleAdmin},
+	TxTypeSetPolicy: {RoleCreator},
+}
+
+// 判断指定角色是否允许执行交易类型。
+func CanRoleExecute(txType TxType, role string) bool {
+	for _, allowed := range txPermissions[txType] {
+		if allowed == role {
+			return true
+		}
+	}
+	return false
+}
+
+// 交易结构
+type Transaction struct {
+	// 网络标识，防止签名在不同集群间复用；omitempty 见 audit.DecodeTransaction
+	ChainID  string `json:"ChainID,omitempty"`
+	Type     TxType
+	Sender   string
+	Receiver string
+	Amount   uint64
+	Nonce    uint64
+	// 可选的过期时间（Unix 秒），0 表示永不过期
+	ValidUntil int64 `json:"ValidUntil,omitempty"`
+	// 附加数据，目前仅 TxTypeSetPolicy 使用
+	Data      []byte `json:"Data,omitempty"`
+	Signature []byte
+}
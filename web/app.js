@@ -182,7 +182,7 @@ const bindFreezeForm = (formId, resultId, endpoint) => {
         sender: data.admin,
         receiver: data.target,
         amount: 0,
-        nonce: 0,
+        nonce: Number(data.nonce),
         private_key: data.key,
       };
       const res = await postJSON(endpoint, payload);
@@ -193,6 +193,30 @@ const bindFreezeForm = (formId, resultId, endpoint) => {
   });
 };
 
+const bindBurnForm = (formId, resultId) => {
+  const form = document.getElementById(formId);
+  const result = document.getElementById(resultId);
+  if (!form) return;
+  form.addEventListener('submit', async (evt) => {
+    evt.preventDefault();
+    const data = Object.fromEntries(new FormData(form).entries());
+    result.textContent = '执行中';
+    try {
+      const payload = {
+        sender: data.sender,
+        receiver: data.sender,
+        amount: Number(data.amount),
+        nonce: Number(data.nonce),
+        private_key: data.key,
+      };
+      const res = await postJSON('/transactions/burn', payload);
+      displayJSON(result, res);
+    } catch (err) {
+      handleError(result, err);
+    }
+  });
+};
+
 const bindQueryForm = (formId, resultId) => {
   const form = document.getElementById(formId);
   const result = document.getElementById(resultId);
@@ -224,6 +248,7 @@ bindAccountLookup('admin-account-form', 'admin-account-result');
 bindTransferForm('admin-transfer-form', 'admin-transfer-result');
 bindFreezeForm('admin-freeze-form', 'admin-freeze-result', '/transactions/freeze');
 bindFreezeForm('admin-unfreeze-form', 'admin-unfreeze-result', '/transactions/unfreeze');
+bindBurnForm('admin-burn-form', 'admin-burn-result');
 bindQueryForm('admin-query-form', 'admin-query-result');
 
 bindAccountLookup('user-account-form', 'user-account-result');